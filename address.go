@@ -0,0 +1,64 @@
+package docker
+
+import (
+	"net"
+)
+
+var ipv4Loopback = net.ParseIP("127.0.0.1")
+var ipv6Loopback = net.ParseIP("::1")
+
+// hostAddresses return the addresses on which the container ports should be published (nil meaning every interfaces), and the address used to reach them.
+func hostAddresses(options Options) ([]net.IP, net.IP) {
+	if options.DualStack {
+		reachable := options.Address
+		if nil == reachable || reachable.IsUnspecified() {
+			reachable = defaultLoopback()
+		}
+		return []net.IP{ipv4Loopback, ipv6Loopback}, reachable
+	}
+	if nil == options.Address {
+		return []net.IP{nil}, defaultLoopback()
+	}
+	if options.Address.IsUnspecified() {
+		return []net.IP{options.Address}, loopbackOf(options.Address)
+	}
+	return []net.IP{options.Address}, options.Address
+}
+
+// defaultLoopback return the IPv4 loopback address, or the IPv6 one if IPv4 is not available on the host (IPv6-only hosts).
+func defaultLoopback() net.IP {
+	listener, err := net.Listen("tcp4", net.JoinHostPort(ipv4Loopback.String(), "0"))
+	if nil != err {
+		return ipv6Loopback
+	}
+	listener.Close()
+	return ipv4Loopback
+}
+
+// loopbackOf return the loopback address of the same family as the given address.
+func loopbackOf(address net.IP) net.IP {
+	if nil == address.To4() {
+		return ipv6Loopback
+	}
+	return ipv4Loopback
+}
+
+// checkedAddresses return the addresses on which port availability should be checked.
+func checkedAddresses(bindings []net.IP, reachable net.IP) []net.IP {
+	checked := make([]net.IP, 0, len(bindings))
+	for _, address := range bindings {
+		if nil == address {
+			address = reachable
+		}
+		checked = append(checked, address)
+	}
+	return checked
+}
+
+// bindingAddresses return the addresses on which the given binding is published, taking its HostIP into account.
+func bindingAddresses(binding PortBinding, defaults []net.IP) []net.IP {
+	if "" == binding.HostIP {
+		return defaults
+	}
+	return []net.IP{net.ParseIP(binding.HostIP)}
+}
@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// AttachStdin attach to the standard input of the container, to drive interactive services and REPL-based tools (eg: a database shell) from the tests. Their output is read from the logs (See Logs, FollowLogs).
+// The container must have been created with Options.OpenStdin. Closing the returned writer close the attachment, and send the end of the input to the container.
+func (c *Container) AttachStdin(ctx context.Context) (io.WriteCloser, error) {
+	inspected, err := c.client.ContainerInspect(ctx, c.Identifier)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting %s", c.Name)
+	}
+	if nil == inspected.Config || !inspected.Config.OpenStdin {
+		return nil, errors.Errorf("Standard input of %s is not open (See Options.OpenStdin)", c.Name)
+	}
+	c.logger.Debugf("Attaching to standard input of %s", c.Name)
+	attached, err := c.client.ContainerAttach(ctx, c.Identifier, types.ContainerAttachOptions{Stream: true, Stdin: true})
+	if nil != err {
+		return nil, errors.Wrapf(err, "Attaching to standard input of %s", c.Name)
+	}
+	return &stdinWriter{attached: attached}, nil
+}
+
+// stdinWriter write to the standard input of an attached container.
+type stdinWriter struct {
+	attached types.HijackedResponse
+	once     sync.Once
+}
+
+func (w *stdinWriter) Write(data []byte) (int, error) {
+	return w.attached.Conn.Write(data)
+}
+
+// Close send the end of the input, and close the attachment.
+func (w *stdinWriter) Close() error {
+	var err error
+	w.once.Do(func() {
+		err = w.attached.CloseWrite()
+		w.attached.Close()
+	})
+	if nil != err {
+		return errors.Wrap(err, "Closing standard input")
+	}
+	return nil
+}
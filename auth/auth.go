@@ -0,0 +1,25 @@
+// Package auth gather the credentials used to pull images from private registries (See docker.Options.RegistryAuth).
+// It is a facade over the root package (docker.RegistryAuth, ...), which stay available: both can be used interchangeably.
+package auth
+
+import (
+	"github.com/normegil/docker"
+)
+
+// Registry are the credentials used to pull images from a private registry.
+type Registry = docker.RegistryAuth
+
+// Basic return the credentials of a registry user.
+func Basic(username string, password string) *Registry {
+	return &Registry{Username: username, Password: password}
+}
+
+// Token return the credentials of an identity token (eg: cloud registries).
+func Token(token string) *Registry {
+	return &Registry{Token: token}
+}
+
+// Configured return the credentials of the docker CLI configuration for the registry hosting the given image, including credential helpers. Nil is returned if no credentials are found.
+func Configured(image string) (*Registry, error) {
+	return docker.ConfiguredRegistryAuth(image)
+}
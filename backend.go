@@ -0,0 +1,188 @@
+package docker
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/sockets"
+	"github.com/pkg/errors"
+)
+
+// ContainerBackend is a container engine serving the docker API (docker daemon, podman service, ...), on which the containers are created.
+type ContainerBackend interface {
+	// Name of the backend, used in logs (eg: docker, podman).
+	Name() string
+	// Host return the address of the API of the backend (eg: unix:///var/run/docker.sock, tcp://127.0.0.1:2376).
+	Host() (string, error)
+}
+
+// DockerBackend is the docker daemon configured like for the docker CLI: by the environment (DOCKER_HOST, DOCKER_API_VERSION, DOCKER_CERT_PATH, DOCKER_TLS_VERIFY), or by the current docker context when DOCKER_HOST is not set.
+type DockerBackend struct {
+	// Context is the name of the docker context to use (See `docker context ls`), instead of DOCKER_HOST or the current context.
+	Context string
+}
+
+// Name return "docker".
+func (DockerBackend) Name() string {
+	return "docker"
+}
+
+// Host return the host of Context if specified, DOCKER_HOST if set, or the host of the current docker context (DOCKER_CONTEXT, or the currentContext of the CLI configuration).
+func (b DockerBackend) Host() (string, error) {
+	name := b.Context
+	if "" == name {
+		if host := os.Getenv("DOCKER_HOST"); "" != host {
+			return host, nil
+		}
+		name = currentContextName()
+	}
+	endpoint, err := loadContext(name)
+	if nil != err {
+		return "", err
+	}
+	return endpoint.Host, nil
+}
+
+// PodmanBackend is a podman service (`podman system service`), which serve a docker compatible API. Rootful and rootless services are supported.
+type PodmanBackend struct {
+	// Socket is the path of the podman API socket, or its address (eg: unix:///run/podman/podman.sock). Default to CONTAINER_HOST, then to the first existing socket among the rootless ($XDG_RUNTIME_DIR/podman/podman.sock) and rootful (/run/podman/podman.sock) defaults.
+	Socket string
+}
+
+// Name return "podman".
+func (PodmanBackend) Name() string {
+	return "podman"
+}
+
+// Host return the address of the podman socket.
+func (b PodmanBackend) Host() (string, error) {
+	if "" != b.Socket {
+		if strings.Contains(b.Socket, "://") {
+			return b.Socket, nil
+		}
+		return "unix://" + b.Socket, nil
+	}
+	if host := os.Getenv("CONTAINER_HOST"); strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "tcp://") {
+		return host, nil
+	}
+	if socket := podmanSocket(); "" != socket {
+		return "unix://" + socket, nil
+	}
+	return "", errors.New("No podman socket found (Start it with: systemctl --user enable --now podman.socket)")
+}
+
+// podmanSocket return the first existing default podman socket, rootless first, or an empty string if there is none.
+func podmanSocket() string {
+	candidates := make([]string, 0, 3)
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); "" != runtimeDir {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	candidates = append(candidates, fmt.Sprintf("/run/user/%d/podman/podman.sock", os.Getuid()), "/run/podman/podman.sock")
+	return firstSocket(candidates)
+}
+
+// localDockerSocket return the socket of the local docker daemon: the default socket if it exists, or else the first existing well-known socket of Colima, Rancher Desktop, Docker Desktop, or rootless docker. The default socket is returned if none exist.
+// macOS and rootless setups then work without exporting DOCKER_HOST.
+func localDockerSocket() string {
+	candidates := []string{defaultDockerSocket}
+	if home, err := os.UserHomeDir(); nil == err {
+		candidates = append(candidates,
+			filepath.Join(home, ".colima", "default", "docker.sock"),
+			filepath.Join(home, ".colima", "docker.sock"),
+			filepath.Join(home, ".rd", "docker.sock"),
+			filepath.Join(home, ".docker", "run", "docker.sock"),
+			filepath.Join(home, ".docker", "desktop", "docker.sock"),
+		)
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); "" != runtimeDir {
+		candidates = append(candidates, filepath.Join(runtimeDir, "docker.sock"))
+	}
+	candidates = append(candidates, fmt.Sprintf("/run/user/%d/docker.sock", os.Getuid()))
+	if socket := firstSocket(candidates); "" != socket {
+		return socket
+	}
+	return defaultDockerSocket
+}
+
+// firstSocket return the first of the given paths which is an existing socket, or an empty string if there is none.
+func firstSocket(candidates []string) string {
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); nil == err && 0 != info.Mode()&os.ModeSocket {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// DetectBackend return the backend to use by default: docker if DOCKER_HOST or a docker context is set, or if a local docker socket exists (See localDockerSocket), podman if only a podman socket exists (eg: CI images shipping podman only), and docker otherwise.
+func DetectBackend() ContainerBackend {
+	if "" != os.Getenv("DOCKER_HOST") || defaultContext != currentContextName() {
+		return DockerBackend{}
+	}
+	if nil == statErr(localDockerSocket()) {
+		return DockerBackend{}
+	}
+	if _, err := (PodmanBackend{}).Host(); nil == err {
+		return PodmanBackend{}
+	}
+	return DockerBackend{}
+}
+
+// backendOf return the backend of the options, detecting it if not specified.
+func backendOf(options Options) ContainerBackend {
+	if nil != options.Backend {
+		return options.Backend
+	}
+	return DetectBackend()
+}
+
+// createClient create a client for the API of the given backend, at the given host.
+func createClient(backend ContainerBackend, host string) (*docker.Client, error) {
+	if dockerBackend, ok := backend.(DockerBackend); ok && "" == dockerBackend.Context && "" != os.Getenv("DOCKER_HOST") {
+		return docker.NewEnvClient()
+	}
+	version := os.Getenv("DOCKER_API_VERSION")
+	if "" == version {
+		version = docker.DefaultVersion
+	}
+	config, err := hostTLS(host)
+	if nil != err {
+		return nil, err
+	}
+	if nil == config {
+		return docker.NewClient(host, version, nil, nil)
+	}
+	proto, address, _, err := docker.ParseHost(host)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Parsing host %s", host)
+	}
+	transport := &http.Transport{TLSClientConfig: config}
+	if err := sockets.ConfigureTransport(transport, proto, address); nil != err {
+		return nil, errors.Wrapf(err, "Configuring transport for %s", host)
+	}
+	return docker.NewClient(host, version, &http.Client{Transport: transport}, nil)
+}
+
+// clientOf return the client of the options, or a new client for their backend.
+func clientOf(options Options, l *eventLogger) (*docker.Client, error) {
+	if nil != options.Client {
+		return options.Client, nil
+	}
+	backend := backendOf(options)
+	l.Debugf("Using %s client", backend.Name())
+	return newClient(backend)
+}
+
+// backendHost return the API address of the backend of the options, for the requests sent directly to the API (See daemonRequest).
+func backendHost(options Options) (string, error) {
+	backend := backendOf(options)
+	host, err := backend.Host()
+	if nil != err {
+		return "", errors.Wrapf(err, "Could not find %s host", backend.Name())
+	}
+	return host, nil
+}
@@ -0,0 +1,127 @@
+package docker
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Backoff define how an operation is retried: delays between attempts start at Initial, are multiplied by Multiplier after each attempt, up to Max.
+// Zero values are replaced by the defaults of the feature using the Backoff.
+type Backoff struct {
+	// Initial delay between the first and second attempts.
+	Initial time.Duration
+	// Max delay between two attempts.
+	Max time.Duration
+	// Multiplier applied to the delay after each attempt (eg: 2 double the delay each time). Values lower than 1 are replaced by the default.
+	Multiplier float64
+	// Jitter randomize each delay by up to this fraction of the delay (eg: 0.2 for +/-20%), to avoid synchronized retries.
+	Jitter float64
+	// MaxAttempts is the maximum number of attempts, including the first one. Negative values mean unlimited attempts (until the context is done).
+	MaxAttempts int
+}
+
+// Retries gather the backoff configurations of the different operations done while creating a container.
+type Retries struct {
+	// Pull is used when pulling the image. Only transient errors (timeouts, connection resets, registry 5xx errors, ...) are retried. Default to 3 attempts, with delays from 1s to 10s.
+	Pull Backoff
+	// Readiness is used between two checks of a wait strategy. Default to delays from 10ms to 250ms, until the startup timeout.
+	Readiness Backoff
+	// Startup is used to recreate the container if it could not be created, started or made ready (eg: random port conflicts). Default to a single attempt.
+	Startup Backoff
+	// Daemon is used for calls to the docker daemon that are expected to fail transiently (eg: inspecting a container which is starting). Default to delays from 10ms to 250ms, until the startup timeout.
+	Daemon Backoff
+}
+
+var defaultPullBackoff = Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2, Jitter: 0.2, MaxAttempts: 3}
+var defaultReadinessBackoff = Backoff{Initial: stepWaitTime, Max: probeWaitTime, Multiplier: 1.5, MaxAttempts: -1}
+var defaultStartupBackoff = Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2, MaxAttempts: 1}
+var defaultDaemonBackoff = Backoff{Initial: stepWaitTime, Max: probeWaitTime, Multiplier: 1.5, MaxAttempts: -1}
+
+// withDefaults replace the zero values of the backoff by the given defaults.
+func (b Backoff) withDefaults(defaults Backoff) Backoff {
+	if 0 >= b.Initial {
+		b.Initial = defaults.Initial
+	}
+	if 0 >= b.Max {
+		b.Max = defaults.Max
+	}
+	if 1 > b.Multiplier {
+		b.Multiplier = defaults.Multiplier
+	}
+	if 0 >= b.Jitter {
+		b.Jitter = defaults.Jitter
+	}
+	if 0 == b.MaxAttempts {
+		b.MaxAttempts = defaults.MaxAttempts
+	}
+	return b
+}
+
+// Delay return the delay to wait after the given attempt (starting at 1).
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt-1))
+	if max := float64(b.Max); 0 < max && delay > max {
+		delay = max
+	}
+	if 0 < b.Jitter {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// Retry call the operation until it succeed, the maximum number of attempts is reached, or the context is done. The last error of the operation is returned.
+// If retryable is not nil, errors for which it return false are returned immediately.
+func (b Backoff) Retry(ctx context.Context, operation func() error, retryable func(error) bool) error {
+	for attempt := 1; ; attempt++ {
+		err := operation()
+		if nil == err {
+			return nil
+		}
+		if nil != retryable && !retryable(err) {
+			return err
+		}
+		if 0 < b.MaxAttempts && attempt >= b.MaxAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(b.Delay(attempt)):
+		}
+	}
+}
+
+// transientErrors are the messages of errors which are expected to disappear when retrying a pull (network issues, registry overloaded or restarting).
+var transientErrors = []string{
+	"timeout",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"unexpected eof",
+	"tls handshake",
+	"500 internal server error",
+	"502 bad gateway",
+	"503 service unavailable",
+	"504 gateway timeout",
+	"temporary failure",
+}
+
+// transientPullError check if a pull error is worth retrying. Errors like unknown images or invalid credentials are not retried.
+func transientPullError(err error) bool {
+	if netErr, ok := errors.Cause(err).(net.Error); ok && netErr.Timeout() {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientErrors {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
+	return false
+}
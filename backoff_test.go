@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testBackoff = Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2, MaxAttempts: -1}
+
+func TestBackoffRetrySuccess(t *testing.T) {
+	attempts := 0
+	err := testBackoff.Retry(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("Not yet")
+		}
+		return nil
+	}, nil)
+	if nil != err {
+		t.Fatalf("Retry: %v", err)
+	}
+	if 3 != attempts {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestBackoffRetryMaxAttempts(t *testing.T) {
+	backoff := testBackoff
+	backoff.MaxAttempts = 4
+	attempts := 0
+	last := errors.New("Last error")
+	err := backoff.Retry(context.Background(), func() error {
+		attempts++
+		if 4 == attempts {
+			return last
+		}
+		return errors.New("Failure")
+	}, nil)
+	if last != err {
+		t.Errorf("Expected the last error, got %v", err)
+	}
+	if 4 != attempts {
+		t.Errorf("Expected 4 attempts, got %d", attempts)
+	}
+}
+
+func TestBackoffRetryNotRetryable(t *testing.T) {
+	permanent := errors.New("Permanent")
+	attempts := 0
+	err := testBackoff.Retry(context.Background(), func() error {
+		attempts++
+		if 2 == attempts {
+			return permanent
+		}
+		return errors.New("Transient")
+	}, func(err error) bool {
+		return permanent != err
+	})
+	if permanent != err {
+		t.Errorf("Expected the permanent error, got %v", err)
+	}
+	if 2 != attempts {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestBackoffRetryContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	failure := errors.New("Failure")
+	start := time.Now()
+	err := testBackoff.Retry(ctx, func() error {
+		return failure
+	}, nil)
+	if failure != err {
+		t.Errorf("Expected the last error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Retry did not stop with the context: %v", elapsed)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	backoff := Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}
+	for attempt, expected := range map[int]time.Duration{
+		1:  10 * time.Millisecond,
+		2:  20 * time.Millisecond,
+		3:  40 * time.Millisecond,
+		4:  50 * time.Millisecond,
+		10: 50 * time.Millisecond,
+	} {
+		if delay := backoff.Delay(attempt); expected != delay {
+			t.Errorf("Attempt %d: expected %v, got %v", attempt, expected, delay)
+		}
+	}
+}
+
+func TestBackoffDelayJitter(t *testing.T) {
+	backoff := Backoff{Initial: 100 * time.Millisecond, Multiplier: 1, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		if delay := backoff.Delay(1); delay < 80*time.Millisecond || delay > 120*time.Millisecond {
+			t.Fatalf("Delay out of the jitter range: %v", delay)
+		}
+	}
+}
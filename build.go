@@ -0,0 +1,295 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/builder/dockerignore"
+	docker "github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// FromDockerfile define how to build the image of the container, instead of using a published image (See Options.FromDockerfile).
+type FromDockerfile struct {
+	// ContextDir is the directory sent to the daemon as build context. Files matching the .dockerignore of the directory are excluded.
+	ContextDir string
+	// Dockerfile is the path of the Dockerfile, relative to ContextDir. Default to "Dockerfile".
+	Dockerfile string
+	// BuildArgs are the values of the ARG instructions of the Dockerfile.
+	BuildArgs map[string]string
+	// Target is the stage to build, for multi-stage Dockerfiles. Default to the last stage.
+	Target string
+	// Tags to give to the built image. Default to a generated tag (normegil-docker-build:<uuid>).
+	Tags []string
+}
+
+// buildImage build the image described by the options, and return its reference.
+func buildImage(client *docker.Client, options Options, build FromDockerfile, l *eventLogger) (string, error) {
+	dockerfile := build.Dockerfile
+	if "" == dockerfile {
+		dockerfile = "Dockerfile"
+	}
+	tags := build.Tags
+	if 0 == len(tags) {
+		tags = []string{"normegil-docker-build:" + uuid.New().String()}
+	}
+
+	l.Printf("Building image %s from %s", tags[0], filepath.Join(build.ContextDir, dockerfile))
+	buildContext, err := buildContext(build.ContextDir, dockerfile)
+	if nil != err {
+		return "", errors.Wrapf(err, "Archiving build context %s", build.ContextDir)
+	}
+	buildArgs := make(map[string]*string, len(build.BuildArgs))
+	for key, value := range build.BuildArgs {
+		value := value
+		buildArgs[key] = &value
+	}
+	imageLabels := map[string]string{
+		LabelSession: SessionID(),
+		LabelCreator: creator,
+		LabelCreated: time.Now().Format(time.RFC3339),
+	}
+
+	var output io.ReadCloser
+	if "" == build.Target {
+		response, err := client.ImageBuild(context.Background(), buildContext, types.ImageBuildOptions{
+			Tags:        tags,
+			Dockerfile:  dockerfile,
+			BuildArgs:   buildArgs,
+			Labels:      imageLabels,
+			Remove:      true,
+			ForceRemove: true,
+		})
+		if nil != err {
+			return "", errors.Wrap(err, "Building image")
+		}
+		output = response.Body
+	} else {
+		// The docker client used by this package predates multi-stage builds: the daemon API is called directly.
+		query := url.Values{}
+		for _, tag := range tags {
+			query.Add("t", tag)
+		}
+		query.Set("dockerfile", dockerfile)
+		query.Set("target", build.Target)
+		query.Set("rm", "1")
+		query.Set("forcerm", "1")
+		encodedArgs, err := json.Marshal(buildArgs)
+		if nil != err {
+			return "", errors.Wrap(err, "Encoding build arguments")
+		}
+		query.Set("buildargs", string(encodedArgs))
+		encodedLabels, err := json.Marshal(imageLabels)
+		if nil != err {
+			return "", errors.Wrap(err, "Encoding image labels")
+		}
+		query.Set("labels", string(encodedLabels))
+		host, err := backendHost(options)
+		if nil != err {
+			return "", err
+		}
+		response, err := daemonRequest(context.Background(), host, http.MethodPost, "/build", query, map[string]string{"Content-Type": "application/x-tar"}, buildContext)
+		if nil != err {
+			return "", errors.Wrap(err, "Building image")
+		}
+		output = response.Body
+	}
+	defer output.Close()
+
+	stream := json.NewDecoder(output)
+	for {
+		var event struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+		}
+		if err := stream.Decode(&event); nil != err {
+			if io.EOF == err {
+				break
+			}
+			return "", errors.Wrap(err, "Building image (Error decoding json stream)")
+		}
+		if "" != event.Error {
+			return "", fmt.Errorf("Building image: %s", event.Error)
+		}
+		if line := strings.TrimSpace(event.Stream); "" != line {
+			l.Debugf("Build: %s", line)
+		}
+	}
+	l.Printf("Image %s built", tags[0])
+	return tags[0], nil
+}
+
+// buildContext archive the build context directory, excluding the files matching its .dockerignore. The Dockerfile and .dockerignore are always included, as the daemon need them.
+func buildContext(directory string, dockerfile string) (io.Reader, error) {
+	var excludes []string
+	ignore, err := os.Open(filepath.Join(directory, ".dockerignore"))
+	if nil == err {
+		excludes, err = dockerignore.ReadAll(ignore)
+		ignore.Close()
+		if nil != err {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "Opening .dockerignore")
+	}
+	matcher, err := newIgnoreMatcher(excludes)
+	if nil != err {
+		return nil, err
+	}
+
+	dockerfile = filepath.ToSlash(filepath.Clean(dockerfile))
+	return archiveDirectory(directory, func(relative string, info os.FileInfo) (bool, error) {
+		if relative != dockerfile && ".dockerignore" != relative && matcher.ignored(relative) {
+			if info.IsDir() && !matcher.hasExceptions {
+				return false, filepath.SkipDir
+			}
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+// archiveDirectory archive the content of the directory, with paths relative to it. Include, if not nil, select the archived paths (slash separated), and can return filepath.SkipDir to skip a whole directory.
+func archiveDirectory(directory string, include func(relative string, info os.FileInfo) (bool, error)) (io.Reader, error) {
+	buffer := &bytes.Buffer{}
+	writer := tar.NewWriter(buffer)
+	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if nil != err {
+			return err
+		}
+		relative, err := filepath.Rel(directory, path)
+		if nil != err {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		if "." == relative {
+			return nil
+		}
+		if nil != include {
+			if included, err := include(relative, info); !included || nil != err {
+				return err
+			}
+		}
+
+		link := ""
+		if 0 != info.Mode()&os.ModeSymlink {
+			if link, err = os.Readlink(path); nil != err {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if nil != err {
+			return err
+		}
+		header.Name = relative
+		if err := writer.WriteHeader(header); nil != err {
+			return errors.Wrapf(err, "Writing header of %s", relative)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if nil != err {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(writer, file); nil != err {
+			return errors.Wrapf(err, "Writing content of %s", relative)
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	if err := writer.Close(); nil != err {
+		return nil, errors.Wrap(err, "Closing archive")
+	}
+	return buffer, nil
+}
+
+// ignoreMatcher match paths against .dockerignore patterns. The last matching pattern win, and patterns starting with "!" are exceptions.
+type ignoreMatcher struct {
+	patterns      []*regexp.Regexp
+	exceptions    []bool
+	hasExceptions bool
+}
+
+func newIgnoreMatcher(patterns []string) (*ignoreMatcher, error) {
+	matcher := &ignoreMatcher{}
+	for _, pattern := range patterns {
+		exception := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(strings.TrimPrefix(pattern, "!"), "/")
+		expression, err := regexp.Compile(ignoreExpression(pattern))
+		if nil != err {
+			return nil, errors.Wrapf(err, "Invalid .dockerignore pattern %s", pattern)
+		}
+		matcher.patterns = append(matcher.patterns, expression)
+		matcher.exceptions = append(matcher.exceptions, exception)
+		matcher.hasExceptions = matcher.hasExceptions || exception
+	}
+	return matcher, nil
+}
+
+// ignored check if the path (relative to the context, with slashes) is excluded. A path is also excluded if one of its parent directories is.
+func (m *ignoreMatcher) ignored(path string) bool {
+	ignored := false
+	for index, pattern := range m.patterns {
+		candidate := path
+		for {
+			if pattern.MatchString(candidate) {
+				ignored = !m.exceptions[index]
+				break
+			}
+			separator := strings.LastIndex(candidate, "/")
+			if -1 == separator {
+				break
+			}
+			candidate = candidate[:separator]
+		}
+	}
+	return ignored
+}
+
+// ignoreExpression convert a .dockerignore pattern into a regular expression.
+func ignoreExpression(pattern string) string {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch character := pattern[i]; character {
+		case '*':
+			if i+2 < len(pattern) && '*' == pattern[i+1] && '/' == pattern[i+2] {
+				expression.WriteString("(.*/)?")
+				i += 2
+			} else if i+1 < len(pattern) && '*' == pattern[i+1] {
+				expression.WriteString(".*")
+				i++
+			} else {
+				expression.WriteString("[^/]*")
+			}
+		case '?':
+			expression.WriteString("[^/]")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+	expression.WriteString("$")
+	return expression.String()
+}
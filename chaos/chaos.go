@@ -0,0 +1,278 @@
+// Package chaos insert a Toxiproxy server (https://github.com/Shopify/toxiproxy) between the tests and the containers they use, to inject network faults on their ports: latency, bandwidth limits, connection resets, ...
+// To start the server, see the New() function, then create a proxy for each port to disturb (See Toxiproxy.Proxy), and connect the tested code to the proxy instead of the container.
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/normegil/docker/wait"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "ghcr.io/shopify/toxiproxy:2.9.0"
+const defaultNetwork = "bridge"
+
+// maxProxies is the number of ports published by the Toxiproxy container, and so the number of proxies it can serve.
+const maxProxies = 16
+
+var apiPort = docker.PortBinding{
+	Protocol:         docker.ProtocolTCP,
+	Internal:         8474,
+	ExternalInterval: "[18474;19474]",
+}
+
+var proxyPorts = docker.PortBinding{
+	Protocol:         docker.ProtocolTCP,
+	Internal:         8666,
+	InternalEnd:      8666 + maxProxies - 1,
+	ExternalInterval: "[20666;22666]",
+}
+
+// Options to configure the Toxiproxy server.
+type Options struct {
+	// Image of the Toxiproxy server. Default to ghcr.io/shopify/toxiproxy:2.9.0.
+	Image string
+	// Network to attach the server to. The proxied containers must be attached to the same network (See docker.Options.Network). Default to the default bridge network.
+	Network string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Toxiproxy is a running Toxiproxy server.
+type Toxiproxy struct {
+	*docker.Container
+	options Options
+	client  *http.Client
+
+	mutex sync.Mutex
+	// proxies is the number of proxies created, used to select the port of the next one.
+	proxies int
+}
+
+// New start a Toxiproxy server, waiting for its API to answer. Terminate should be called to remove the container.
+func New(options Options) (*Toxiproxy, error) {
+	options = withDefaults(options)
+	c, err := core.Start(core.Options{
+		Name:         "toxiproxy",
+		Image:        options.Image,
+		Ports:        []docker.PortBinding{apiPort, proxyPorts},
+		Network:      networkOption(options.Network),
+		WaitStrategy: wait.ForHTTP(apiPort, "/version", func(status int, body []byte) bool { return http.StatusOK == status }),
+		Logger:       options.Logger,
+	})
+	if nil != err {
+		return nil, err
+	}
+	return &Toxiproxy{Container: c, options: options, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.Network {
+		options.Network = defaultNetwork
+	}
+	return options
+}
+
+// networkOption return the value of docker.Options.Network attaching the container to the given network: the default bridge network is used when no network is specified.
+func networkOption(network string) string {
+	if defaultNetwork == network {
+		return ""
+	}
+	return network
+}
+
+// Proxy create a proxy forwarding the connections to the given port of the target container. The tested code should then connect to the proxy (See Proxy.Endpoint), instead of the target.
+// The target must be attached to the network of the server (See Options.Network).
+func (t *Toxiproxy) Proxy(ctx context.Context, target *docker.Container, binding docker.PortBinding) (*Proxy, error) {
+	address, err := target.NetworkAddress(ctx, t.options.Network)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Finding address of %s", target.Name)
+	}
+
+	t.mutex.Lock()
+	if maxProxies <= t.proxies {
+		t.mutex.Unlock()
+		return nil, errors.Errorf("Toxiproxy cannot serve more than %d proxies", maxProxies)
+	}
+	listen := proxyPorts.Internal + t.proxies
+	t.proxies++
+	t.mutex.Unlock()
+
+	proxy := &Proxy{
+		Name:      fmt.Sprintf("%s-%d", target.Name, binding.Internal),
+		toxiproxy: t,
+		listen:    listen,
+	}
+	err = t.call(ctx, http.MethodPost, "/proxies", map[string]interface{}{
+		"name":     proxy.Name,
+		"listen":   net.JoinHostPort("0.0.0.0", strconv.Itoa(listen)),
+		"upstream": net.JoinHostPort(address.String(), strconv.Itoa(binding.Internal)),
+		"enabled":  true,
+	})
+	if nil != err {
+		return nil, errors.Wrapf(err, "Creating proxy for %s", target.Name)
+	}
+	return proxy, nil
+}
+
+// Reset remove the toxics of every proxy, and enable them again, to start the next test from a healthy network.
+func (t *Toxiproxy) Reset(ctx context.Context) error {
+	return t.call(ctx, http.MethodPost, "/reset", nil)
+}
+
+// call send a request to the Toxiproxy API, with the given body encoded in JSON.
+func (t *Toxiproxy) call(ctx context.Context, method string, path string, body interface{}) error {
+	var content []byte
+	if nil != body {
+		var err error
+		content, err = json.Marshal(body)
+		if nil != err {
+			return errors.Wrap(err, "Encoding request")
+		}
+	}
+	request, err := http.NewRequest(method, "http://"+t.Endpoint(apiPort)+path, bytes.NewReader(content))
+	if nil != err {
+		return errors.Wrapf(err, "Creating request %s %s", method, path)
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	response, err := t.client.Do(request)
+	if nil != err {
+		return errors.Wrapf(err, "Calling %s %s", method, path)
+	}
+	defer response.Body.Close()
+	if 300 <= response.StatusCode {
+		message, _ := ioutil.ReadAll(response.Body)
+		return errors.Errorf("%s %s failed (Status: %d): %s", method, path, response.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
+
+// Stream is the direction of the traffic a toxic apply to.
+type Stream string
+
+// Streams of a proxied connection.
+const (
+	// Downstream is the traffic from the target container to the tested code.
+	Downstream Stream = "downstream"
+	// Upstream is the traffic from the tested code to the target container.
+	Upstream Stream = "upstream"
+)
+
+// Toxic is a fault applied on the connections of a proxy. See https://github.com/Shopify/toxiproxy#toxics for the available types and their attributes.
+type Toxic struct {
+	Name string
+	// Type of the toxic (eg: latency, bandwidth, reset_peer, timeout, slow_close, slicer, limit_data).
+	Type string
+	// Stream to apply the toxic to. Default to Downstream.
+	Stream Stream
+	// Toxicity is the probability for a connection to be affected, between 0 and 1. Default to 1.
+	Toxicity float64
+	// Attributes of the toxic, depending on its type (eg: latency and jitter, in milliseconds, for latency).
+	Attributes map[string]interface{}
+}
+
+// Proxy is a proxy forwarding the connections to a port of a container, on which toxics can be added.
+type Proxy struct {
+	// Name of the proxy in the Toxiproxy server.
+	Name string
+
+	toxiproxy *Toxiproxy
+	// listen is the internal port of the Toxiproxy container the proxy listen on.
+	listen int
+}
+
+// Endpoint return the "host:port" address the tested code should connect to.
+func (p *Proxy) Endpoint() string {
+	info := p.toxiproxy.ContainerInfo
+	port := info.HostPort(proxyPorts, p.listen)
+	if info.Direct {
+		port = p.listen
+	}
+	return net.JoinHostPort(info.Address.String(), strconv.Itoa(port))
+}
+
+// AddToxic add the given toxic to the proxy. It apply to the open connections, and to the new ones.
+func (p *Proxy) AddToxic(ctx context.Context, toxic Toxic) error {
+	if "" == toxic.Stream {
+		toxic.Stream = Downstream
+	}
+	if 0 == toxic.Toxicity {
+		toxic.Toxicity = 1
+	}
+	if "" == toxic.Name {
+		toxic.Name = toxic.Type + "_" + string(toxic.Stream)
+	}
+	err := p.toxiproxy.call(ctx, http.MethodPost, "/proxies/"+p.Name+"/toxics", map[string]interface{}{
+		"name":       toxic.Name,
+		"type":       toxic.Type,
+		"stream":     toxic.Stream,
+		"toxicity":   toxic.Toxicity,
+		"attributes": toxic.Attributes,
+	})
+	if nil != err {
+		return errors.Wrapf(err, "Adding toxic %s to %s", toxic.Name, p.Name)
+	}
+	return nil
+}
+
+// RemoveToxic remove the toxic with the given name from the proxy.
+func (p *Proxy) RemoveToxic(ctx context.Context, name string) error {
+	if err := p.toxiproxy.call(ctx, http.MethodDelete, "/proxies/"+p.Name+"/toxics/"+name, nil); nil != err {
+		return errors.Wrapf(err, "Removing toxic %s from %s", name, p.Name)
+	}
+	return nil
+}
+
+// AddLatency delay the data sent by the target container by latency, plus or minus jitter. The toxic is named "latency_downstream".
+func (p *Proxy) AddLatency(ctx context.Context, latency time.Duration, jitter time.Duration) error {
+	return p.AddToxic(ctx, Toxic{Type: "latency", Attributes: map[string]interface{}{
+		"latency": latency.Milliseconds(),
+		"jitter":  jitter.Milliseconds(),
+	}})
+}
+
+// LimitBandwidth limit the data sent by the target container to the given rate, in KB/s. The toxic is named "bandwidth_downstream".
+func (p *Proxy) LimitBandwidth(ctx context.Context, rate int) error {
+	return p.AddToxic(ctx, Toxic{Type: "bandwidth", Attributes: map[string]interface{}{
+		"rate": rate,
+	}})
+}
+
+// ResetConnections reset (TCP RST) the connections after the given delay, and the new connections once the delay elapsed after their opening. The toxic is named "reset_peer_downstream".
+func (p *Proxy) ResetConnections(ctx context.Context, after time.Duration) error {
+	return p.AddToxic(ctx, Toxic{Type: "reset_peer", Attributes: map[string]interface{}{
+		"timeout": after.Milliseconds(),
+	}})
+}
+
+// Disable close the open connections and refuse the new ones, like if the target container was down. See Enable to restore the proxy.
+func (p *Proxy) Disable(ctx context.Context) error {
+	return p.setEnabled(ctx, false)
+}
+
+// Enable accept again the connections of a disabled proxy (See Disable).
+func (p *Proxy) Enable(ctx context.Context) error {
+	return p.setEnabled(ctx, true)
+}
+
+func (p *Proxy) setEnabled(ctx context.Context, enabled bool) error {
+	if err := p.toxiproxy.call(ctx, http.MethodPost, "/proxies/"+p.Name, map[string]interface{}{"enabled": enabled}); nil != err {
+		return errors.Wrapf(err, "Updating proxy %s", p.Name)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// checkpointHint explain the requirements of checkpoints, added to their errors.
+const checkpointHint = "checkpoints are experimental: the daemon must run with experimental features enabled, and CRIU installed"
+
+// Checkpoint save the state of the processes of the running container (memory, open files, ...) under the given name, with CRIU. The container keep running.
+// A warmed-up container (eg: a JVM application after its startup, a seeded database) can then be checkpointed once, and restored between the tests in a fraction of its startup time (See Restore).
+// Checkpoints are an experimental feature of the daemon, which must run with experimental features enabled and CRIU installed. They are removed with the container.
+func (c *Container) Checkpoint(ctx context.Context, name string) error {
+	c.logger.Printf("Checkpointing container %s as %s", c.Name, name)
+	if err := c.client.CheckpointCreate(ctx, c.Identifier, types.CheckpointCreateOptions{CheckpointID: name}); nil != err {
+		return errors.Wrapf(err, "Checkpointing %s (%s)", c.Name, checkpointHint)
+	}
+	return nil
+}
+
+// Restore stop the container, and start it again from the given checkpoint (See Checkpoint): its processes resume in the state they were checkpointed, without running their startup again.
+// Changes made to the filesystem of the container since the checkpoint are kept: services storing their state on disk (eg: databases) need their data directory on a tmpfs (See TmpfsDataDirectory) to be fully reset.
+func (c *Container) Restore(ctx context.Context, name string) error {
+	c.stopWatchers()
+	c.logger.Printf("Restoring container %s from checkpoint %s", c.Name, name)
+	timeout := time.Duration(0)
+	if err := c.client.ContainerStop(ctx, c.Identifier, &timeout); nil != err {
+		return errors.Wrapf(err, "Stopping %s", c.Name)
+	}
+	if err := c.client.ContainerStart(ctx, c.Identifier, types.ContainerStartOptions{CheckpointID: name}); nil != err {
+		return errors.Wrapf(err, "Restoring %s from checkpoint %s (%s)", c.Name, name, checkpointHint)
+	}
+	return c.resume(false)
+}
+
+// DeleteCheckpoint remove the given checkpoint of the container.
+func (c *Container) DeleteCheckpoint(ctx context.Context, name string) error {
+	c.logger.Printf("Deleting checkpoint %s of container %s", name, c.Name)
+	if err := c.client.CheckpointDelete(ctx, c.Identifier, types.CheckpointDeleteOptions{CheckpointID: name}); nil != err {
+		return errors.Wrapf(err, "Deleting checkpoint %s of %s", name, c.Name)
+	}
+	return nil
+}
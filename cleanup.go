@@ -0,0 +1,104 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// cleanupTimeout is the maximum time given to the removal of the session resources, when the tests are over or interrupted.
+const cleanupTimeout = 30 * time.Second
+
+// CleanupSession remove the containers, networks and volumes created by the current session (See SessionID), except the reusable containers (See Options.Reuse).
+// It is called by RunTests once the tests are over, and by HandleSignals when the process is interrupted.
+func CleanupSession(ctx context.Context) error {
+	session := SessionID()
+	return removeCreated(ctx, "session resources", func(labels map[string]string) bool {
+		return session == labels[LabelSession] && "" == labels[LabelReuse]
+	})
+}
+
+// cleanupSession remove the resources of the session, and disconnect from the reaper, unless they are kept for debugging (See Config.Keep). Errors are written on the standard error, as no logger is available at this point.
+func cleanupSession() error {
+	if loaded, _ := LoadConfig(); loaded.Keep {
+		fmt.Fprintf(os.Stderr, "Keeping containers of session %s (See %s)\n", SessionID(), EnvKeep)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	err := CleanupSession(ctx)
+	if nil != err {
+		fmt.Fprintf(os.Stderr, "Could not clean up session %s: %+v\n", SessionID(), err)
+	}
+	// The reaper remove what could not be removed, and itself
+	if closeErr := closeReaper(); nil != closeErr {
+		fmt.Fprintf(os.Stderr, "Could not disconnect from reaper of session %s: %+v\n", SessionID(), closeErr)
+	}
+	return err
+}
+
+// HandleSignals remove the resources of the session (See CleanupSession) when the process is interrupted (SIGINT, eg: Ctrl-C, or SIGTERM), before letting the signal terminate the process.
+// Without it, interrupted test runs leak their containers (unless the reaper is enabled, See Config.Reaper). The returned function stop handling the signals.
+func HandleSignals() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case received := <-signals:
+			signal.Stop(signals)
+			fmt.Fprintf(os.Stderr, "Received %s, removing containers of session %s\n", received, SessionID())
+			cleanupSession()
+			terminate(received)
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+		})
+	}
+}
+
+// terminate send again the signal to the process, now that it is not handled anymore, so that the process exit with the status expected by its parent (eg: the shell, the CI runner).
+func terminate(received os.Signal) {
+	if process, err := os.FindProcess(os.Getpid()); nil == err && nil == process.Signal(received) {
+		time.Sleep(time.Second)
+	}
+	// The signal could not be sent (eg: os.Interrupt on Windows)
+	os.Exit(1)
+}
+
+// TestingM is the subset of *testing.M used by RunTests.
+type TestingM interface {
+	Run() int
+}
+
+// RunTests run the tests (m.Run), then call the given cleanup functions and remove the resources of the session (See CleanupSession). The resources are also removed if TestMain panics, or if the process is interrupted (See HandleSignals).
+// It return the exit code to pass to os.Exit, which is not 0 if the resources could not be removed:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(docker.RunTests(m))
+//	}
+//
+// A panic in a test terminates the process without running any deferred function: enable the reaper (See Config.Reaper) to remove the resources in this case too.
+func RunTests(m TestingM, cleanups ...func()) (code int) {
+	stop := HandleSignals()
+	defer stop()
+	defer func() {
+		for _, cleanup := range cleanups {
+			cleanup()
+		}
+		if err := cleanupSession(); nil != err && 0 == code {
+			code = 1
+		}
+	}()
+	return m.Run()
+}
@@ -0,0 +1,77 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/versions"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// clients are the clients shared by the whole process, indexed by backend and host. Creating a client per container multiply the connections to the daemon, and the API version negotiations.
+var clients = struct {
+	sync.Mutex
+	byHost map[string]*docker.Client
+}{byHost: make(map[string]*docker.Client)}
+
+// newClient return the client shared by the process for the API of the given backend (detected if nil), creating it on first use.
+// The API version of the client is negotiated with the daemon (See negotiateVersion), unless DOCKER_API_VERSION is set. If the daemon cannot be reached, the client is returned without being shared, and the negotiation is retried on the next call.
+func newClient(backend ContainerBackend) (*docker.Client, error) {
+	if nil == backend {
+		backend = DetectBackend()
+	}
+	host, err := backend.Host()
+	if nil != err {
+		return nil, errors.Wrapf(err, "Could not find %s host", backend.Name())
+	}
+	key := backend.Name() + "|" + host
+
+	clients.Lock()
+	defer clients.Unlock()
+	if client, ok := clients.byHost[key]; ok {
+		return client, nil
+	}
+	client, err := createClient(backend, host)
+	if nil != err {
+		return nil, err
+	}
+	if "" != os.Getenv("DOCKER_API_VERSION") {
+		clients.byHost[key] = client
+		return client, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), availabilityTimeout)
+	defer cancel()
+	version, err := negotiateVersion(ctx, host, client.ClientVersion())
+	if nil != err {
+		return client, nil
+	}
+	client.UpdateClientVersion(version)
+	clients.byHost[key] = client
+	return client, nil
+}
+
+// negotiateVersion return the API version to use with the daemon: the version of the client if the daemon support it, the latest version of the daemon if it is older, or the oldest version supported by the daemon if it is newer.
+// Newer daemons refuse old API versions ("client version is too old"), but still accept the requests of the old client on their oldest supported version.
+func negotiateVersion(ctx context.Context, host string, clientVersion string) (string, error) {
+	response, err := daemonRequest(ctx, host, http.MethodGet, "/version", nil, nil, nil)
+	if nil != err {
+		return "", errors.Wrap(err, "Requesting daemon version")
+	}
+	defer response.Body.Close()
+	var version types.Version
+	if err := json.NewDecoder(response.Body).Decode(&version); nil != err {
+		return "", errors.Wrap(err, "Parsing daemon version")
+	}
+	switch {
+	case "" != version.APIVersion && versions.LessThan(version.APIVersion, clientVersion):
+		return version.APIVersion, nil
+	case "" != version.MinAPIVersion && versions.GreaterThan(version.MinAPIVersion, clientVersion):
+		return version.MinAPIVersion, nil
+	}
+	return clientVersion, nil
+}
@@ -0,0 +1,396 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/normegil/interval"
+)
+
+// ErrDryRun is returned by New when Options.DryRun is set: the equivalent docker commands were logged, but nothing was executed.
+var ErrDryRun = errors.New("Dry run: container not created")
+
+// DockerRunCommand render the `docker run` command equivalent to the given options, to reproduce a test container manually.
+// Host ports are rendered as ranges (ExternalInterval), letting the daemon select an available port. Files and wait strategies have no equivalent and are ignored.
+func DockerRunCommand(options Options) string {
+	args := []string{"docker", "run", "--detach"}
+	if "" != options.Name {
+		args = append(args, "--name", options.Name)
+	}
+	for _, port := range publishedPorts(options) {
+		args = append(args, "--publish", port)
+	}
+	if options.PublishAllPorts {
+		args = append(args, "--publish-all")
+	}
+	for _, file := range options.EnvFiles {
+		args = append(args, "--env-file", file)
+	}
+	for _, variable := range sortedEnvironment(options) {
+		args = append(args, "--env", variable)
+	}
+	for _, key := range sortedKeys(options.Labels) {
+		args = append(args, "--label", key+"="+options.Labels[key])
+	}
+	if "" != options.PullPolicy {
+		args = append(args, "--pull", string(options.PullPolicy))
+	}
+	if "" != options.Platform {
+		args = append(args, "--platform", options.Platform)
+	}
+	if "" != options.Network {
+		args = append(args, "--network", options.Network)
+	}
+	for _, alias := range options.NetworkAliases {
+		args = append(args, "--network-alias", alias)
+	}
+	args = append(args, runFlags(options)...)
+	if 0 != len(options.Entrypoint) {
+		// docker run only accept the executable of the entrypoint, its arguments are prepended to the command
+		args = append(args, "--entrypoint", options.Entrypoint[0])
+	}
+	args = append(args, options.Image)
+	if 0 != len(options.Entrypoint) {
+		args = append(args, options.Entrypoint[1:]...)
+	}
+	args = append(args, options.Cmd...)
+
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// DockerRunCommand render the `docker run` command equivalent to the container, publishing the host ports selected for it, to reproduce the container outside of the tests (See DockerRunCommand).
+func (c *Container) DockerRunCommand() string {
+	options := c.options
+	options.Ports = make([]PortBinding, 0, len(c.options.Ports))
+	for _, binding := range c.options.Ports {
+		if port, ok := c.Ports[binding]; ok {
+			binding.ExternalInterval = "[" + strconv.Itoa(port) + ";" + strconv.Itoa(port) + "]"
+		}
+		options.Ports = append(options.Ports, binding)
+	}
+	return DockerRunCommand(options)
+}
+
+// ComposeService render a docker-compose service definition equivalent to the given options.
+func ComposeService(options Options) string {
+	name := options.Name
+	if "" == name {
+		name = "service"
+	}
+	lines := []string{"services:", "  " + name + ":", "    image: " + yamlQuote(options.Image)}
+	if "" != options.PullPolicy {
+		lines = append(lines, "    pull_policy: "+string(options.PullPolicy))
+	}
+	if "" != options.Platform {
+		lines = append(lines, "    platform: "+yamlQuote(options.Platform))
+	}
+	appendList := func(key string, values []string) {
+		if 0 == len(values) {
+			return
+		}
+		lines = append(lines, "    "+key+":")
+		for _, value := range values {
+			lines = append(lines, "      - "+yamlQuote(value))
+		}
+	}
+	appendList("entrypoint", options.Entrypoint)
+	appendList("command", options.Cmd)
+	appendList("ports", publishedPorts(options))
+	appendList("env_file", options.EnvFiles)
+	appendList("environment", sortedEnvironment(options))
+	labels := make([]string, 0, len(options.Labels))
+	for _, key := range sortedKeys(options.Labels) {
+		labels = append(labels, key+"="+options.Labels[key])
+	}
+	appendList("labels", labels)
+	appendList("cap_add", options.CapAdd)
+	appendList("cap_drop", options.CapDrop)
+	appendList("security_opt", options.SecurityOpt)
+	appendList("group_add", options.GroupAdd)
+	devices := make([]string, 0, len(options.Devices))
+	for _, device := range toDockerDevices(options.Devices) {
+		devices = append(devices, device.PathOnHost+":"+device.PathInContainer+":"+device.CgroupPermissions)
+	}
+	appendList("devices", devices)
+	appendList("volumes", toDockerBinds(options.Binds))
+	appendList("tmpfs", tmpfsMounts(options))
+	if options.ReadonlyRootfs {
+		lines = append(lines, "    read_only: true")
+	}
+	if options.Init {
+		lines = append(lines, "    init: true")
+	}
+	if options.Tty {
+		lines = append(lines, "    tty: true")
+	}
+	if options.OpenStdin {
+		lines = append(lines, "    stdin_open: true")
+	}
+	if options.Privileged {
+		lines = append(lines, "    privileged: true")
+	}
+	if "" != options.RestartPolicy.Name {
+		policy := options.RestartPolicy.Name
+		if 0 != options.RestartPolicy.MaximumRetryCount {
+			policy += ":" + strconv.Itoa(options.RestartPolicy.MaximumRetryCount)
+		}
+		lines = append(lines, "    restart: "+yamlQuote(policy))
+	}
+	if "" != runtime(options) {
+		lines = append(lines, "    runtime: "+yamlQuote(runtime(options)))
+	}
+	if "" != options.Isolation {
+		lines = append(lines, "    isolation: "+yamlQuote(options.Isolation))
+	}
+	if "" != options.CgroupParent {
+		lines = append(lines, "    cgroup_parent: "+yamlQuote(options.CgroupParent))
+	}
+	if "" != options.PidMode {
+		lines = append(lines, "    pid: "+yamlQuote(options.PidMode))
+	}
+	if "" != options.IpcMode {
+		lines = append(lines, "    ipc: "+yamlQuote(options.IpcMode))
+	}
+	if "" != options.UsernsMode {
+		lines = append(lines, "    userns_mode: "+yamlQuote(options.UsernsMode))
+	}
+	if 0 != options.Resources.Memory {
+		lines = append(lines, "    mem_limit: "+strconv.FormatInt(options.Resources.Memory, 10))
+	}
+	if 0 != options.Resources.MemorySwap {
+		lines = append(lines, "    memswap_limit: "+strconv.FormatInt(options.Resources.MemorySwap, 10))
+	}
+	if 0 != options.Resources.NanoCPUs {
+		lines = append(lines, "    cpus: "+formatCPUs(options.Resources.NanoCPUs))
+	}
+	if 0 != options.Resources.PidsLimit {
+		lines = append(lines, "    pids_limit: "+strconv.FormatInt(options.Resources.PidsLimit, 10))
+	}
+	if 0 != options.Resources.ShmSize {
+		lines = append(lines, "    shm_size: "+strconv.FormatInt(options.Resources.ShmSize, 10))
+	}
+	if 0 != len(options.Sysctls) {
+		lines = append(lines, "    sysctls:")
+		for _, key := range sortedKeys(options.Sysctls) {
+			lines = append(lines, "      "+key+": "+yamlQuote(options.Sysctls[key]))
+		}
+	}
+	if "" != options.LogConfig.Driver || 0 != len(options.LogConfig.Options) {
+		lines = append(lines, "    logging:")
+		if "" != options.LogConfig.Driver {
+			lines = append(lines, "      driver: "+yamlQuote(options.LogConfig.Driver))
+		}
+		if 0 != len(options.LogConfig.Options) {
+			lines = append(lines, "      options:")
+			for _, key := range sortedKeys(options.LogConfig.Options) {
+				lines = append(lines, "        "+key+": "+yamlQuote(options.LogConfig.Options[key]))
+			}
+		}
+	}
+	if 0 != len(options.Ulimits) {
+		lines = append(lines, "    ulimits:")
+		for _, ulimit := range options.Ulimits {
+			lines = append(lines, "      "+ulimit.Name+":", "        soft: "+strconv.FormatInt(ulimit.Soft, 10), "        hard: "+strconv.FormatInt(ulimit.Hard, 10))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// runFlags return the docker run flags for the options not related to ports and environment.
+func runFlags(options Options) []string {
+	flags := make([]string, 0)
+	resources := options.Resources
+	if 0 != resources.Memory {
+		flags = append(flags, "--memory", strconv.FormatInt(resources.Memory, 10))
+	}
+	if 0 != resources.MemorySwap {
+		flags = append(flags, "--memory-swap", strconv.FormatInt(resources.MemorySwap, 10))
+	}
+	if 0 != resources.NanoCPUs {
+		flags = append(flags, "--cpus", formatCPUs(resources.NanoCPUs))
+	}
+	if 0 != resources.CPUPeriod {
+		flags = append(flags, "--cpu-period", strconv.FormatInt(resources.CPUPeriod, 10))
+	}
+	if 0 != resources.CPUQuota {
+		flags = append(flags, "--cpu-quota", strconv.FormatInt(resources.CPUQuota, 10))
+	}
+	if 0 != resources.PidsLimit {
+		flags = append(flags, "--pids-limit", strconv.FormatInt(resources.PidsLimit, 10))
+	}
+	if 0 != resources.ShmSize {
+		flags = append(flags, "--shm-size", strconv.FormatInt(resources.ShmSize, 10))
+	}
+	for _, ulimit := range options.Ulimits {
+		flags = append(flags, "--ulimit", fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+	for _, key := range sortedKeys(options.Sysctls) {
+		flags = append(flags, "--sysctl", key+"="+options.Sysctls[key])
+	}
+	for _, bind := range options.Binds {
+		flags = append(flags, "--volume", bind.String())
+	}
+	if "" != options.LogConfig.Driver {
+		flags = append(flags, "--log-driver", options.LogConfig.Driver)
+	}
+	for _, key := range sortedKeys(options.LogConfig.Options) {
+		flags = append(flags, "--log-opt", key+"="+options.LogConfig.Options[key])
+	}
+	if options.ReadonlyRootfs {
+		flags = append(flags, "--read-only")
+	}
+	if options.Init {
+		flags = append(flags, "--init")
+	}
+	if options.Tty {
+		flags = append(flags, "--tty")
+	}
+	if options.OpenStdin {
+		flags = append(flags, "--interactive")
+	}
+	for _, mount := range tmpfsMounts(options) {
+		flags = append(flags, "--tmpfs", mount)
+	}
+	for _, device := range toDockerDevices(options.Devices) {
+		flags = append(flags, "--device", device.PathOnHost+":"+device.PathInContainer+":"+device.CgroupPermissions)
+	}
+	if "" != runtime(options) {
+		flags = append(flags, "--runtime", runtime(options))
+	}
+	if "" != options.Isolation {
+		flags = append(flags, "--isolation", options.Isolation)
+	}
+	if "" != options.RestartPolicy.Name {
+		policy := options.RestartPolicy.Name
+		if 0 != options.RestartPolicy.MaximumRetryCount {
+			policy += ":" + strconv.Itoa(options.RestartPolicy.MaximumRetryCount)
+		}
+		flags = append(flags, "--restart", policy)
+	}
+	if options.AutoRemove {
+		flags = append(flags, "--rm")
+	}
+	if "" != options.StopSignal {
+		flags = append(flags, "--stop-signal", options.StopSignal)
+	}
+	if 0 < options.StopTimeout {
+		flags = append(flags, "--stop-timeout", strconv.Itoa(int(options.StopTimeout.Seconds())))
+	}
+	if options.Privileged {
+		flags = append(flags, "--privileged")
+	}
+	for _, capability := range options.CapAdd {
+		flags = append(flags, "--cap-add", capability)
+	}
+	for _, capability := range options.CapDrop {
+		flags = append(flags, "--cap-drop", capability)
+	}
+	for _, option := range options.SecurityOpt {
+		flags = append(flags, "--security-opt", option)
+	}
+	for _, group := range options.GroupAdd {
+		flags = append(flags, "--group-add", group)
+	}
+	if "" != options.CgroupParent {
+		flags = append(flags, "--cgroup-parent", options.CgroupParent)
+	}
+	if "" != options.PidMode {
+		flags = append(flags, "--pid", options.PidMode)
+	}
+	if "" != options.IpcMode {
+		flags = append(flags, "--ipc", options.IpcMode)
+	}
+	if "" != options.UsernsMode {
+		flags = append(flags, "--userns", options.UsernsMode)
+	}
+	return flags
+}
+
+// publishedPorts render the port bindings as docker run --publish values ([hostIP:]hostPorts:containerPorts/protocol).
+func publishedPorts(options Options) []string {
+	bindAddresses, _ := hostAddresses(options)
+	published := make([]string, 0)
+	for _, binding := range options.Ports {
+		hostPorts := binding.ExternalInterval
+		if parsed, err := interval.ParseIntervalInteger(binding.ExternalInterval); nil == err {
+			lowest := parsed.LowestNumberIncluded()
+			highest := parsed.HighestNumberIncluded()
+			if binding.size() > 1 {
+				highest = lowest + binding.size() - 1
+			}
+			hostPorts = strconv.Itoa(lowest)
+			if highest != lowest {
+				hostPorts += "-" + strconv.Itoa(highest)
+			}
+		}
+		containerPorts := strconv.Itoa(binding.Internal)
+		if binding.size() > 1 {
+			containerPorts += "-" + strconv.Itoa(binding.lastInternal())
+		}
+		for _, address := range bindingAddresses(binding, bindAddresses) {
+			prefix := ""
+			if nil != address {
+				prefix = address.String() + ":"
+				if nil == address.To4() {
+					prefix = "[" + address.String() + "]:"
+				}
+			}
+			published = append(published, prefix+hostPorts+":"+containerPorts+"/"+binding.protocol())
+		}
+	}
+	return published
+}
+
+// tmpfsMounts render the tmpfs mounts as docker run --tmpfs values (path[:options]).
+func tmpfsMounts(options Options) []string {
+	tmpfs := tmpfs(options)
+	mounts := make([]string, 0, len(tmpfs))
+	for _, path := range sortedKeys(tmpfs) {
+		mount := path
+		if "" != tmpfs[path] {
+			mount += ":" + tmpfs[path]
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts
+}
+
+func sortedEnvironment(options Options) []string {
+	variables := environment(options)
+	sort.Strings(variables)
+	return variables
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatCPUs(nanoCPUs int64) string {
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
+// shellQuote quote the argument for a POSIX shell, if needed.
+func shellQuote(arg string) string {
+	if "" != arg && -1 == strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,@%+[]", r))
+	}) {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'"'"'`, -1) + "'"
+}
+
+func yamlQuote(value string) string {
+	return strconv.Quote(value)
+}
@@ -0,0 +1,416 @@
+// Package compose start the services described by a docker-compose file, using the same containers lifecycle and wait strategies as github.com/normegil/docker.
+// Only the most common service settings are supported: image, command, entrypoint, environment, ports, depends_on, labels, volumes (bind mounts), tmpfs, read_only, init, tty, stdin_open, restart, privileged, cap_add and cap_drop.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultExternalInterval is the range of host ports used for the container ports published without an explicit host port.
+const defaultExternalInterval = "[32768;60999]"
+
+// Options customize how the stack is started.
+type Options struct {
+	// WaitStrategies define, per service name, when a service is ready. Default to waiting for the first published port of the service.
+	WaitStrategies map[string]docker.WaitStrategy
+	// Logger used by the stack and its containers.
+	Logger docker.Logger
+	// Progress, if specified, track the startup of the services.
+	Progress *docker.Progress
+}
+
+// Stack is a started docker-compose file.
+type Stack struct {
+	*docker.Environment
+}
+
+// Up parse the docker-compose file, and start its services in dependency order. Relative bind mounts are resolved from the directory of the file.
+func Up(ctx context.Context, path string, options Options) (*Stack, error) {
+	content, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Reading %s", path)
+	}
+	directory, err := filepath.Abs(filepath.Dir(path))
+	if nil != err {
+		return nil, errors.Wrapf(err, "Resolving directory of %s", path)
+	}
+	group, err := Parse(content, directory)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Parsing %s", path)
+	}
+	group.Logger = options.Logger
+	group.Progress = options.Progress
+	for name, member := range group.Members {
+		member.Options.Logger = options.Logger
+		if strategy, ok := options.WaitStrategies[name]; ok {
+			member.Options.WaitStrategy = strategy
+		}
+		group.Members[name] = member
+	}
+	environment, err := group.Start(ctx)
+	if nil != err {
+		return nil, err
+	}
+	return &Stack{Environment: environment}, nil
+}
+
+// Down remove the containers and the network of the stack.
+func (s *Stack) Down(ctx context.Context) error {
+	return s.Terminate(ctx)
+}
+
+// Port return the port to use to reach the given container port of the service (See docker.ContainerInfo.Port), or 0 if it is not published.
+func (s *Stack) Port(service string, internal int) int {
+	c, binding, ok := s.binding(service, internal)
+	if !ok {
+		return 0
+	}
+	return c.ContainerInfo.Port(binding) + internal - binding.Internal
+}
+
+// Endpoint return the "host:port" address to use to reach the given container port of the service, or an empty string if it is not published.
+func (s *Stack) Endpoint(service string, internal int) string {
+	c, binding, ok := s.binding(service, internal)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(c.ContainerInfo.Endpoint(binding))
+	if nil != err {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(s.Port(service, internal)))
+}
+
+// binding return the container of the service, and its port binding publishing the given container port.
+func (s *Stack) binding(service string, internal int) (*docker.Container, docker.PortBinding, bool) {
+	c := s.Get(service)
+	if nil == c {
+		return nil, docker.PortBinding{}, false
+	}
+	for binding := range c.Ports {
+		if 0 != c.HostPort(binding, internal) {
+			return c, binding, true
+		}
+	}
+	return nil, docker.PortBinding{}, false
+}
+
+// file is the subset of the docker-compose format supported by this package.
+type file struct {
+	Services map[string]service `yaml:"services"`
+}
+
+type service struct {
+	Image       string      `yaml:"image"`
+	Command     interface{} `yaml:"command"`
+	Entrypoint  interface{} `yaml:"entrypoint"`
+	Environment interface{} `yaml:"environment"`
+	Ports       []string    `yaml:"ports"`
+	DependsOn   interface{} `yaml:"depends_on"`
+	Labels      interface{} `yaml:"labels"`
+	Volumes     []string    `yaml:"volumes"`
+	Tmpfs       interface{} `yaml:"tmpfs"`
+	ReadOnly    bool        `yaml:"read_only"`
+	Init        bool        `yaml:"init"`
+	Tty         bool        `yaml:"tty"`
+	StdinOpen   bool        `yaml:"stdin_open"`
+	Restart     string      `yaml:"restart"`
+	Privileged  bool        `yaml:"privileged"`
+	CapAdd      []string    `yaml:"cap_add"`
+	CapDrop     []string    `yaml:"cap_drop"`
+}
+
+// Parse convert the content of a docker-compose file into a docker.Group. Relative bind mounts are resolved from the given directory.
+func Parse(content []byte, directory string) (docker.Group, error) {
+	var parsed file
+	if err := yaml.Unmarshal(content, &parsed); nil != err {
+		return docker.Group{}, errors.Wrap(err, "Parsing YAML")
+	}
+	group := docker.Group{Members: make(map[string]docker.GroupMember, len(parsed.Services))}
+	for name, service := range parsed.Services {
+		member, err := service.member(name, directory)
+		if nil != err {
+			return docker.Group{}, errors.Wrapf(err, "Service %s", name)
+		}
+		group.Members[name] = member
+	}
+	return group, nil
+}
+
+func (s service) member(name string, directory string) (docker.GroupMember, error) {
+	if "" == s.Image {
+		return docker.GroupMember{}, errors.New("Only services with an image are supported")
+	}
+	options := core.Options{
+		Name:           name,
+		Image:          s.Image,
+		Privileged:     s.Privileged,
+		ReadonlyRootfs: s.ReadOnly,
+		Init:           s.Init,
+		Tty:            s.Tty,
+		OpenStdin:      s.StdinOpen,
+		CapAdd:         s.CapAdd,
+		CapDrop:        s.CapDrop,
+	}
+	var err error
+	if options.Cmd, err = command(s.Command); nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "command")
+	}
+	if options.Entrypoint, err = command(s.Entrypoint); nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "entrypoint")
+	}
+	if options.EnvironmentVariables, err = mapping(s.Environment); nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "environment")
+	}
+	if options.Labels, err = mapping(s.Labels); nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "labels")
+	}
+	for _, port := range s.Ports {
+		binding, err := portBinding(port)
+		if nil != err {
+			return docker.GroupMember{}, errors.Wrapf(err, "port %s", port)
+		}
+		options.Ports = append(options.Ports, binding)
+	}
+	if 0 == len(options.Ports) {
+		options.PublishAllPorts = true
+	}
+	for _, volume := range s.Volumes {
+		bind, err := bindMount(volume, directory)
+		if nil != err {
+			return docker.GroupMember{}, errors.Wrapf(err, "volume %s", volume)
+		}
+		options.Binds = append(options.Binds, bind)
+	}
+	tmpfs, err := list(s.Tmpfs)
+	if nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "tmpfs")
+	}
+	if 0 != len(tmpfs) {
+		options.TmpfsMounts = make(map[string]string, len(tmpfs))
+		for _, mount := range tmpfs {
+			parts := strings.SplitN(mount, ":", 2)
+			if 2 == len(parts) {
+				options.TmpfsMounts[parts[0]] = parts[1]
+			} else {
+				options.TmpfsMounts[parts[0]] = ""
+			}
+		}
+	}
+	if "" != s.Restart {
+		parts := strings.SplitN(s.Restart, ":", 2)
+		options.RestartPolicy.Name = parts[0]
+		if 2 == len(parts) {
+			if options.RestartPolicy.MaximumRetryCount, err = strconv.Atoi(parts[1]); nil != err {
+				return docker.GroupMember{}, errors.Wrap(err, "restart")
+			}
+		}
+	}
+	dependencies, err := dependsOn(s.DependsOn)
+	if nil != err {
+		return docker.GroupMember{}, errors.Wrap(err, "depends_on")
+	}
+	return docker.GroupMember{Options: options, DependsOn: dependencies}, nil
+}
+
+// command parse a command, written as a string (split like a shell would) or a list.
+func command(value interface{}) ([]string, error) {
+	if text, ok := value.(string); ok {
+		return split(text)
+	}
+	return list(value)
+}
+
+// list parse a value written as a single string or as a list of strings.
+func list(value interface{}) ([]string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{typed}, nil
+	case []interface{}:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("Unsupported value: %v", value)
+}
+
+// mapping parse a value written as a map, or as a list of KEY=VALUE strings.
+func mapping(value interface{}) (map[string]string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case map[interface{}]interface{}:
+		values := make(map[string]string, len(typed))
+		for key, item := range typed {
+			if nil == item {
+				item = ""
+			}
+			values[fmt.Sprint(key)] = fmt.Sprint(item)
+		}
+		return values, nil
+	case []interface{}:
+		values := make(map[string]string, len(typed))
+		for _, item := range typed {
+			parts := strings.SplitN(fmt.Sprint(item), "=", 2)
+			if 2 == len(parts) {
+				values[parts[0]] = parts[1]
+			} else {
+				values[parts[0]] = ""
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("Unsupported value: %v", value)
+}
+
+// dependsOn parse the dependencies of a service, written as a list of names, or as a map of conditions indexed by name. Conditions are ignored: dependencies must always be ready (See Options.WaitStrategies).
+func dependsOn(value interface{}) ([]string, error) {
+	if conditions, ok := value.(map[interface{}]interface{}); ok {
+		dependencies := make([]string, 0, len(conditions))
+		for name := range conditions {
+			dependencies = append(dependencies, fmt.Sprint(name))
+		}
+		sort.Strings(dependencies)
+		return dependencies, nil
+	}
+	return list(value)
+}
+
+// portBinding parse a port, in the short syntax: [[IP:]HOST:]CONTAINER[/PROTOCOL], where HOST and CONTAINER can be ranges (eg: 8000-8010).
+func portBinding(port string) (docker.PortBinding, error) {
+	binding := docker.PortBinding{Protocol: docker.ProtocolTCP, ExternalInterval: defaultExternalInterval}
+	if index := strings.LastIndex(port, "/"); -1 != index {
+		binding.Protocol = port[index+1:]
+		port = port[:index]
+	}
+	parts := strings.Split(port, ":")
+	containerPorts := parts[len(parts)-1]
+	switch len(parts) {
+	case 1:
+	case 2, 3:
+		if 3 == len(parts) {
+			binding.HostIP = parts[0]
+		}
+		if host := parts[len(parts)-2]; "" != host {
+			first, last, err := portRange(host)
+			if nil != err {
+				return binding, err
+			}
+			binding.ExternalInterval = "[" + strconv.Itoa(first) + ";" + strconv.Itoa(last) + "]"
+		}
+	default:
+		return binding, errors.New("Unsupported port syntax")
+	}
+	first, last, err := portRange(containerPorts)
+	if nil != err {
+		return binding, err
+	}
+	binding.Internal = first
+	if last != first {
+		binding.InternalEnd = last
+	}
+	return binding, nil
+}
+
+// portRange parse a port (eg: 8080) or a range of ports (eg: 8000-8010).
+func portRange(ports string) (int, int, error) {
+	parts := strings.SplitN(ports, "-", 2)
+	first, err := strconv.Atoi(parts[0])
+	if nil != err {
+		return 0, 0, errors.Wrapf(err, "Parsing port %s", parts[0])
+	}
+	if 1 == len(parts) {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(parts[1])
+	if nil != err {
+		return 0, 0, errors.Wrapf(err, "Parsing port %s", parts[1])
+	}
+	return first, last, nil
+}
+
+// bindMount parse a volume, in the short syntax: HOST:CONTAINER[:ro]. Relative host paths are relative to the directory of the compose file, and ~ is the home directory of the current user. Named volumes are not supported.
+func bindMount(volume string, directory string) (docker.Bind, error) {
+	parts := strings.Split(volume, ":")
+	if len(parts) < 2 {
+		return docker.Bind{}, errors.New("Anonymous volumes are not supported")
+	}
+	host := parts[0]
+	if !strings.HasPrefix(host, ".") && !filepath.IsAbs(host) && !strings.HasPrefix(host, "~") {
+		return docker.Bind{}, errors.New("Named volumes are not supported")
+	}
+	if "~" == host || strings.HasPrefix(host, "~/") {
+		home, err := os.UserHomeDir()
+		if nil != err {
+			return docker.Bind{}, errors.Wrapf(err, "Expanding %s", host)
+		}
+		host = filepath.Join(home, strings.TrimPrefix(host, "~"))
+	} else if strings.HasPrefix(host, "~") {
+		return docker.Bind{}, errors.Errorf("Home directories of other users are not supported: %s", host)
+	}
+	if !filepath.IsAbs(host) {
+		host = filepath.Join(directory, host)
+	}
+	return docker.Bind{HostPath: host, ContainerPath: parts[1], ReadOnly: 3 == len(parts) && "ro" == parts[2]}, nil
+}
+
+// split split a command line in arguments, like a shell would (whitespaces, single and double quotes, backslash escapes).
+func split(text string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArgument := false
+	var quote rune
+	escaped := false
+	for _, character := range text {
+		switch {
+		case escaped:
+			current.WriteRune(character)
+			escaped = false
+		case '\\' == character && '\'' != quote:
+			escaped = true
+			inArgument = true
+		case 0 != quote:
+			if character == quote {
+				quote = 0
+			} else {
+				current.WriteRune(character)
+			}
+		case '\'' == character || '"' == character:
+			quote = character
+			inArgument = true
+		case ' ' == character || '\t' == character || '\n' == character:
+			if inArgument {
+				args = append(args, current.String())
+				current.Reset()
+				inArgument = false
+			}
+		default:
+			current.WriteRune(character)
+			inArgument = true
+		}
+	}
+	if 0 != quote {
+		return nil, errors.New("Unterminated quote in " + text)
+	}
+	if inArgument {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
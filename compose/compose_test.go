@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/normegil/docker"
+)
+
+func TestPortBinding(t *testing.T) {
+	for _, test := range []struct {
+		port     string
+		expected docker.PortBinding
+	}{
+		{port: "80", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: defaultExternalInterval}},
+		{port: "8080:80", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: "[8080;8080]"}},
+		{port: ":80", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: defaultExternalInterval}},
+		{port: "127.0.0.1:8080:80", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: "[8080;8080]", HostIP: "127.0.0.1"}},
+		{port: "127.0.0.1::80", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: defaultExternalInterval, HostIP: "127.0.0.1"}},
+		{port: "53:53/udp", expected: docker.PortBinding{Protocol: docker.ProtocolUDP, Internal: 53, ExternalInterval: "[53;53]"}},
+		{port: "9000-9010:8000-8010", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 8000, InternalEnd: 8010, ExternalInterval: "[9000;9010]"}},
+		{port: "8000-8010", expected: docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 8000, InternalEnd: 8010, ExternalInterval: defaultExternalInterval}},
+	} {
+		binding, err := portBinding(test.port)
+		if nil != err {
+			t.Errorf("%s: %v", test.port, err)
+			continue
+		}
+		if test.expected != binding {
+			t.Errorf("%s: expected %+v, got %+v", test.port, test.expected, binding)
+		}
+	}
+}
+
+func TestPortBindingErrors(t *testing.T) {
+	for _, port := range []string{"", "http", "8080:http", "1:2:3:4", "a-b"} {
+		if binding, err := portBinding(port); nil == err {
+			t.Errorf("%q: expected an error, got %+v", port, binding)
+		}
+	}
+}
+
+func TestBindMount(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if nil != err {
+		t.Skipf("No home directory: %v", err)
+	}
+	directory := filepath.Join(string(filepath.Separator), "project")
+	absolute := filepath.Join(string(filepath.Separator), "data")
+	for _, test := range []struct {
+		volume   string
+		expected docker.Bind
+	}{
+		{volume: "./config:/etc/config", expected: docker.Bind{HostPath: filepath.Join(directory, "config"), ContainerPath: "/etc/config"}},
+		{volume: "../shared:/shared:ro", expected: docker.Bind{HostPath: filepath.Join(string(filepath.Separator), "shared"), ContainerPath: "/shared", ReadOnly: true}},
+		{volume: absolute + ":/data:rw", expected: docker.Bind{HostPath: absolute, ContainerPath: "/data"}},
+		{volume: "~/cache:/cache", expected: docker.Bind{HostPath: filepath.Join(home, "cache"), ContainerPath: "/cache"}},
+		{volume: "~:/home/user", expected: docker.Bind{HostPath: home, ContainerPath: "/home/user"}},
+	} {
+		bind, err := bindMount(test.volume, directory)
+		if nil != err {
+			t.Errorf("%s: %v", test.volume, err)
+			continue
+		}
+		if test.expected != bind {
+			t.Errorf("%s: expected %+v, got %+v", test.volume, test.expected, bind)
+		}
+	}
+}
+
+func TestBindMountErrors(t *testing.T) {
+	for _, volume := range []string{"/data", "named:/data", "~other/data:/data"} {
+		if bind, err := bindMount(volume, "/project"); nil == err {
+			t.Errorf("%q: expected an error, got %+v", volume, bind)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Environment variables overriding the configuration file (See Config).
+const (
+	EnvConfigFile     = "TESTDOCKER_CONFIG"
+	EnvStartupTimeout = "TESTDOCKER_STARTUP_TIMEOUT"
+	EnvPullPolicy     = "TESTDOCKER_PULL_POLICY"
+	EnvRegistryMirror = "TESTDOCKER_REGISTRY_MIRROR"
+	EnvImageRewrites  = "TESTDOCKER_IMAGE_REWRITES"
+	EnvKeep           = "TESTDOCKER_KEEP"
+	EnvReaper         = "TESTDOCKER_REAPER"
+	EnvProxy          = "TESTDOCKER_PROXY_ENV"
+)
+
+// Config are the defaults applied to every container, allowing CI environments to tune the package without code changes. Values specified in Options take precedence.
+// The configuration is read once, from the file ~/.testdocker.yaml (or TESTDOCKER_CONFIG) if it exists, then from the TESTDOCKER_* environment variables, which take precedence.
+type Config struct {
+	// StartupTimeout is the default of Options.StartupTimeout (TESTDOCKER_STARTUP_TIMEOUT, eg: 30s).
+	StartupTimeout time.Duration `yaml:"startup_timeout"`
+	// PullPolicy is the default of Options.PullPolicy (TESTDOCKER_PULL_POLICY).
+	PullPolicy PullPolicy `yaml:"pull_policy"`
+	// RegistryMirror is a registry mirroring Docker Hub (eg: mirror.corp:5000), from which Docker Hub images are pulled (TESTDOCKER_REGISTRY_MIRROR).
+	RegistryMirror string `yaml:"registry_mirror"`
+	// ImageRewrites are substitutions applied to the images before pulling them, for registries which are rate-limited or not reachable (TESTDOCKER_IMAGE_REWRITES, as a comma separated list of from=to rules, eg: docker.io/*=mirror.corp/*).
+	// The first matching rule is applied. RegistryMirror, if specified, is applied after them.
+	ImageRewrites []ImageRewrite `yaml:"image_rewrites"`
+	// Keep, if true, keep the containers after the tests instead of removing them, to debug them (TESTDOCKER_KEEP).
+	Keep bool `yaml:"keep"`
+	// Reaper, if true, start a reaper with the first container, removing the containers of the session if the tests are killed (TESTDOCKER_REAPER, See StartReaper).
+	Reaper bool `yaml:"reaper"`
+	// ProxyEnvironment, if true, propagate the proxy settings of the host into the containers which do not specify Options.ProxyEnvironment (TESTDOCKER_PROXY_ENV).
+	ProxyEnvironment bool `yaml:"proxy_environment"`
+}
+
+var config struct {
+	once   sync.Once
+	config Config
+	err    error
+}
+
+// LoadConfig return the configuration of the package (See Config). It is read once, and the same configuration (or error) is returned afterwards.
+func LoadConfig() (Config, error) {
+	config.once.Do(func() {
+		config.config, config.err = readConfig()
+	})
+	return config.config, config.err
+}
+
+func readConfig() (Config, error) {
+	loaded := Config{}
+	path := os.Getenv(EnvConfigFile)
+	if "" == path {
+		if home, err := os.UserHomeDir(); nil == err {
+			path = filepath.Join(home, ".testdocker.yaml")
+		}
+	}
+	if "" != path {
+		content, err := ioutil.ReadFile(path)
+		if nil != err && !os.IsNotExist(err) {
+			return loaded, errors.Wrapf(err, "Reading configuration %s", path)
+		}
+		if err := yaml.UnmarshalStrict(content, &loaded); nil != err {
+			return loaded, errors.Wrapf(err, "Parsing configuration %s", path)
+		}
+	}
+
+	if value := os.Getenv(EnvStartupTimeout); "" != value {
+		timeout, err := time.ParseDuration(value)
+		if nil != err {
+			return loaded, errors.Wrapf(err, "Parsing %s", EnvStartupTimeout)
+		}
+		loaded.StartupTimeout = timeout
+	}
+	if value := os.Getenv(EnvPullPolicy); "" != value {
+		loaded.PullPolicy = PullPolicy(value)
+	}
+	if value := os.Getenv(EnvRegistryMirror); "" != value {
+		loaded.RegistryMirror = value
+	}
+	if value := os.Getenv(EnvImageRewrites); "" != value {
+		loaded.ImageRewrites = nil
+		for _, rule := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+			if 2 != len(parts) {
+				return loaded, errors.Errorf("Parsing %s: invalid rule %q (expected from=to)", EnvImageRewrites, rule)
+			}
+			loaded.ImageRewrites = append(loaded.ImageRewrites, ImageRewrite{From: parts[0], To: parts[1]})
+		}
+	}
+	for variable, target := range map[string]*bool{EnvKeep: &loaded.Keep, EnvReaper: &loaded.Reaper, EnvProxy: &loaded.ProxyEnvironment} {
+		if value := os.Getenv(variable); "" != value {
+			enabled, err := strconv.ParseBool(value)
+			if nil != err {
+				return loaded, errors.Wrapf(err, "Parsing %s", variable)
+			}
+			*target = enabled
+		}
+	}
+	return loaded, nil
+}
+
+// withConfig apply the configuration to the options which are not specified.
+func withConfig(options Options, loaded Config) Options {
+	if 0 == options.StartupTimeout {
+		options.StartupTimeout = loaded.StartupTimeout
+	}
+	if "" == options.PullPolicy {
+		options.PullPolicy = loaded.PullPolicy
+	}
+	if "" == options.ProxyEnvironment && loaded.ProxyEnvironment {
+		options.ProxyEnvironment = ProxyEnabled
+	}
+	rules := loaded.ImageRewrites
+	if "" != loaded.RegistryMirror {
+		rules = append(rules[:len(rules):len(rules)], ImageRewrite{From: "docker.io/*", To: strings.TrimSuffix(loaded.RegistryMirror, "/") + "/*"})
+	}
+	options.Image = rewriteImage(options.Image, rules)
+	return options
+}
+
+// reaper is the reaper started by the configuration (See Config.Reaper).
+// The reaper is kept referenced until the end of the session: its connection would otherwise be closed by the garbage collector, and the reaper would remove the containers of the session during the tests.
+var reaper struct {
+	once      sync.Once
+	closeOnce sync.Once
+	started   *Reaper
+	err       error
+}
+
+// ensureReaper start the reaper of the session, if it was not started yet.
+func ensureReaper(logger Logger) error {
+	reaper.once.Do(func() {
+		reaper.started, reaper.err = StartReaper(logger)
+	})
+	return reaper.err
+}
+
+// closeReaper disconnect from the reaper started by the configuration, if any, which then remove the resources of the session. It can be called several times (eg: on a signal received during RunTests cleanup).
+func closeReaper() error {
+	var err error
+	reaper.closeOnce.Do(func() {
+		if nil != reaper.started {
+			err = reaper.started.Close()
+		}
+	})
+	return err
+}
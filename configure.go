@@ -0,0 +1,61 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// configurationScript is the script written in containers started in two phases (See Options.Configure), running the command of the container with its configuration.
+const configurationScript = "/.docker-configuration.sh"
+
+// awaitConfiguration is the entrypoint of the containers started in two phases: it wait for the configuration script, and run it.
+var awaitConfiguration = []string{"/bin/sh", "-c", "while [ ! -f " + configurationScript + " ]; do sleep 0.1; done; exec /bin/sh " + configurationScript}
+
+// configure run the second phase of a container started in two phases: the started container, whose ports are now known, is given to Options.Configure, and the configuration script, exporting the returned variables before running the command of the container, is copied in the container.
+// The command is Options.Entrypoint and Options.Cmd, defaulting to the command of the image (like docker run, an Entrypoint in the options discard the Cmd of the image).
+// The script is kept in the container: when the container is restarted, the command run with the same configuration.
+func configure(ctx context.Context, c *Container) error {
+	c.logger.Debugf("Configuring container: " + c.Name)
+	variables, err := c.options.Configure(ctx, c)
+	if nil != err {
+		return errors.Wrap(err, "Configuring container")
+	}
+	lines := []string{"#!/bin/sh"}
+	keys := make([]string, 0, len(variables))
+	for key := range variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, "export "+key+"="+shellQuote(variables[key]))
+	}
+	if 0 != len(c.options.Secrets) {
+		// The secrets are written again each time the container is started (See writeSecrets)
+		lines = append(lines, "while [ ! -f "+secretsReady+" ]; do sleep 0.1; done")
+	}
+	command := make([]string, 0)
+	for _, arg := range configuredCommand(c.options, c.Image) {
+		command = append(command, shellQuote(arg))
+	}
+	if 0 == len(command) {
+		return errors.New("Configuring container: No command to run (the image and the options don't define any)")
+	}
+	lines = append(lines, "exec "+strings.Join(command, " "))
+	script := File{ContainerPath: configurationScript, Content: []byte(strings.Join(lines, "\n") + "\n"), Mode: 0755}
+	return c.CopyFiles(ctx, script)
+}
+
+// configuredCommand return the command run by a container started in two phases.
+func configuredCommand(options Options, image ImageInfo) []string {
+	if 0 != len(options.Entrypoint) {
+		return append(append([]string{}, options.Entrypoint...), options.Cmd...)
+	}
+	command := append([]string{}, image.Entrypoint...)
+	if 0 != len(options.Cmd) {
+		return append(command, options.Cmd...)
+	}
+	return append(command, image.Cmd...)
+}
@@ -0,0 +1,89 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dockerProxies are the processes used by docker to forward the published ports to the containers. They are expected to be listening on the published ports.
+var dockerProxies = []string{"docker-proxy", "rootlessport", "vpnkit", "slirp4netns"}
+
+// portConflict return a description of the process, other than a docker proxy, listening on the given TCP host port. An empty string is returned if there is no such process, or if it cannot be identified (eg: non-Linux hosts, or process owned by another user).
+// A silent conflict usually happen when a port was free when selected, but another process bound it before the container was started.
+func portConflict(port int) string {
+	inodes := listeningInodes(port)
+	if 0 == len(inodes) {
+		return ""
+	}
+	processes, err := filepath.Glob("/proc/[0-9]*")
+	if nil != err {
+		return ""
+	}
+	for _, process := range processes {
+		descriptors, err := ioutil.ReadDir(filepath.Join(process, "fd"))
+		if nil != err {
+			continue
+		}
+		for _, descriptor := range descriptors {
+			link, err := os.Readlink(filepath.Join(process, "fd", descriptor.Name()))
+			if nil != err || !inodes[link] {
+				continue
+			}
+			name := processName(process)
+			if isDockerProxy(name) {
+				return ""
+			}
+			return fmt.Sprintf("Host port %d is bound by another process: %s (PID: %s)", port, name, filepath.Base(process))
+		}
+	}
+	return ""
+}
+
+// listeningInodes return the socket inodes (formatted as the /proc/<pid>/fd links) of the TCP sockets listening on the given port.
+func listeningInodes(port int) map[string]bool {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		file, err := os.Open(table)
+		if nil != err {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Scan() // Header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || "0A" != fields[3] {
+				continue
+			}
+			separator := strings.LastIndex(fields[1], ":")
+			local, err := strconv.ParseInt(fields[1][separator+1:], 16, 32)
+			if nil != err || int(local) != port {
+				continue
+			}
+			inodes["socket:["+fields[9]+"]"] = true
+		}
+		file.Close()
+	}
+	return inodes
+}
+
+func processName(process string) string {
+	name, err := ioutil.ReadFile(filepath.Join(process, "comm"))
+	if nil != err {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(name))
+}
+
+func isDockerProxy(name string) bool {
+	for _, proxy := range dockerProxies {
+		if proxy == name {
+			return true
+		}
+	}
+	return false
+}
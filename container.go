@@ -0,0 +1,303 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+const cpuPeriod = 100000
+
+// Container is a handle on a running container, allowing to act on it during the tests. See Start().
+type Container struct {
+	ContainerInfo
+	// Name of the container.
+	Name string
+
+	client   *docker.Client
+	logger   *eventLogger
+	options  Options
+	strategy WaitStrategy
+	// external is true for containers not created by this package (See FromExisting).
+	external bool
+	// sidecars are the containers sharing the network namespace of this container (See Options.Sidecars).
+	sidecars []*Container
+	// disconnected are the settings of the networks the container was disconnected from, restored when reconnecting it (See DisconnectNetwork).
+	disconnected map[string]*network.EndpointSettings
+	// timings are the durations of the startup phases (See Timings).
+	timings StartupTimings
+	// watchers are the functions to call to stop the goroutines watching the container (Supervisor, Reload, ...).
+	watchers []func()
+}
+
+// Terminate stop watching the container, remove its sidecars, and remove it (unless it is reusable, See Options.Reuse, or kept for debugging, See Config.Keep). If Options.StopSignal or Options.StopTimeout were specified, the container is gracefully stopped before being removed.
+func (c *Container) Terminate(ctx context.Context) error {
+	if err := runHook(ctx, "PreTerminate", c.options.Hooks.PreTerminate, c); nil != err {
+		c.logger.Warnf("%s: %+v", c.Name, err)
+	}
+	if loaded, _ := LoadConfig(); loaded.Keep {
+		c.stopWatchers()
+		c.logger.Printf("Keeping container (See %s): %s", EnvKeep, c.Name)
+		return nil
+	}
+	if "" != c.options.StopSignal || 0 < c.options.StopTimeout {
+		// Without StopTimeout, the daemon use the stop timeout of the container (10 seconds by default)
+		var timeout *time.Duration
+		if 0 < c.options.StopTimeout {
+			timeout = &c.options.StopTimeout
+		}
+		if err := c.stop(ctx, timeout); nil != err {
+			c.logger.Warnf("Could not stop %s gracefully: %+v", c.Name, err)
+		}
+	}
+	c.stopWatchers()
+	if err := c.terminateSidecars(ctx); nil != err {
+		c.logger.Errorf("Could not terminate sidecars of %s: %+v", c.Name, err)
+	}
+	if c.external {
+		return nil
+	}
+	if c.options.Reuse {
+		c.logger.Printf("Keeping reusable container: " + c.Name)
+		return nil
+	}
+
+	c.logger.Printf("Removing container: " + c.Name)
+	if err := c.client.ContainerRemove(ctx, c.Identifier, types.ContainerRemoveOptions{Force: true}); nil != err {
+		if _, inspectErr := c.client.ContainerInspect(ctx, c.Identifier); docker.IsErrContainerNotFound(inspectErr) {
+			// Already removed by the daemon (See Options.AutoRemove)
+			return nil
+		}
+		return lifecycleError(PhaseRemove, c.Name, c.options, err)
+	}
+	return nil
+}
+
+// Update are the changes to apply to a running container (See Container.Update). Zero values leave the current settings unchanged.
+type Update struct {
+	// Resources limits to apply. ShmSize cannot be changed on a running container, and is ignored.
+	Resources Resources
+	// RestartPolicy, if not nil, replace the restart policy of the container.
+	RestartPolicy *RestartPolicy
+}
+
+// Update change the resource limits or the restart policy of the running container, allowing to tighten limits during a test without recreating the container.
+func (c *Container) Update(ctx context.Context, update Update) error {
+	config := container.UpdateConfig{Resources: toDockerResources(update.Resources)}
+	if nil != update.RestartPolicy {
+		config.RestartPolicy = container.RestartPolicy{
+			Name:              update.RestartPolicy.Name,
+			MaximumRetryCount: update.RestartPolicy.MaximumRetryCount,
+		}
+	}
+	c.logger.Printf("Updating container %s: %+v", c.Name, update)
+	return c.update(ctx, config)
+}
+
+// Stop send the configured stop signal (See Options.StopSignal) to the container, and wait for it to exit. After timeout, the container is killed.
+// Supervision and other watchers are stopped first, so that stopping the container is not considered as a crash.
+func (c *Container) Stop(ctx context.Context, timeout time.Duration) error {
+	return c.stop(ctx, &timeout)
+}
+
+// stop stop the container (See Stop). If timeout is nil, the daemon use the stop timeout of the container.
+func (c *Container) stop(ctx context.Context, timeout *time.Duration) error {
+	c.stopWatchers()
+	if nil == timeout {
+		c.logger.Printf("Stopping container: %s", c.Name)
+	} else {
+		c.logger.Printf("Stopping container: %s (Timeout: %+v)", c.Name, *timeout)
+	}
+	if err := c.client.ContainerStop(ctx, c.Identifier, timeout); nil != err {
+		return errors.Wrapf(err, "Stopping %s", c.Name)
+	}
+	return nil
+}
+
+// Kill send the given signal (eg: SIGKILL, SIGTERM, SIGHUP) to the main process of the container, to simulate a crash of the service.
+// Unlike Stop, the watchers are kept: a supervised container which die is handled like any crash (See Supervisor).
+func (c *Container) Kill(ctx context.Context, signal string) error {
+	c.logger.Printf("Sending %s to container: %s", signal, c.Name)
+	if err := c.client.ContainerKill(ctx, c.Identifier, signal); nil != err {
+		return errors.Wrapf(err, "Sending %s to %s", signal, c.Name)
+	}
+	return nil
+}
+
+// Restart stop the container (killing it after timeout), and start it again. If rewait is true, the wait strategy of the container is run again before returning, so that the service is ready to be used (See Options.WaitStrategy).
+// Host ports assigned by the daemon (See Options.PublishAllPorts) can change, and are updated.
+func (c *Container) Restart(ctx context.Context, timeout time.Duration, rewait bool) error {
+	c.stopWatchers()
+	c.logger.Printf("Restarting container: %s (Timeout: %+v)", c.Name, timeout)
+	if err := c.client.ContainerRestart(ctx, c.Identifier, &timeout); nil != err {
+		return errors.Wrapf(err, "Restarting %s", c.Name)
+	}
+	return c.resume(rewait)
+}
+
+// resume update the information of the container started again (See refreshInfo), wait for it to be ready if rewait is true, and start watching it again.
+func (c *Container) resume(rewait bool) error {
+	if err := c.refreshInfo(); nil != err {
+		return err
+	}
+	if 0 != len(c.options.Secrets) {
+		// The tmpfs holding the secrets was emptied when the container stopped
+		c.logger.Printf("Writing secrets in container: " + c.Name)
+		if err := writeSecrets(context.Background(), c.client, c.Identifier, c.options.Secrets); nil != err {
+			return lifecycleError(PhaseStart, c.Name, c.options, err)
+		}
+	}
+	if rewait {
+		c.logger.with(Fields{"phase": PhaseWait}).Printf("Waiting for container: " + c.Name)
+		peak := followMemoryPeak(c.client, c.Identifier, c.options)
+		if err := waitContainer(c.client, c.ContainerInfo, c.strategy, startupTimeout(c.options), withDefaultRetries(c.options), nil); nil != err {
+			return lifecycleError(PhaseWait, c.Name, c.options, startupFailure(c.client, c.Identifier, peak(), errors.Wrap(err, "Restarted container not ready within time limit")))
+		}
+		peak()
+	}
+	c.startWatchers()
+	return nil
+}
+
+// refreshInfo update the host ports assigned by the daemon (See Options.PublishAllPorts), and the address of the container when it is reached directly, which can change when the container is started again.
+func (c *Container) refreshInfo() error {
+	info := c.ContainerInfo
+	if c.options.PublishAllPorts {
+		info.Ports = make(map[PortBinding]int, len(c.Ports))
+		for _, binding := range c.options.Ports {
+			if port, ok := c.Ports[binding]; ok {
+				info.Ports[binding] = port
+			}
+		}
+		if err := addPublishedPorts(c.client, c.Identifier, info.Ports); nil != err {
+			return errors.Wrapf(err, "Listing published ports of %s", c.Name)
+		}
+	}
+	if info.Direct {
+		// The container can get another address on its networks
+		if err := reachFromContainer(c.client, c.options, &info); nil != err {
+			return errors.Wrapf(err, "Finding address of %s", c.Name)
+		}
+	}
+	c.ContainerInfo = info
+	return nil
+}
+
+// startWatchers start the goroutines watching the container, as configured in the options (See Options.Supervisor, Options.Reload).
+func (c *Container) startWatchers() {
+	if nil != c.options.Supervisor {
+		c.logger.Printf("Supervising container: " + c.Name)
+		c.watchers = append(c.watchers, c.options.Supervisor.watch(supervised{
+			client:   c.client,
+			logger:   c.logger,
+			info:     c.ContainerInfo,
+			name:     c.Name,
+			strategy: c.strategy,
+			timeout:  startupTimeout(c.options),
+			retries:  withDefaultRetries(c.options),
+			secrets:  c.options.Secrets,
+		}))
+	}
+	if nil != c.options.Reload && 0 != len(c.options.Binds) {
+		c.logger.Printf("Watching bind-mounted paths of container: " + c.Name)
+		c.watchers = append(c.watchers, c.options.Reload.watch(c.client, c.logger, c.Identifier, c.Name, c.options.Binds))
+	}
+}
+
+func (c *Container) stopWatchers() {
+	for _, stop := range c.watchers {
+		stop()
+	}
+	c.watchers = nil
+}
+
+// ThrottleCPU limit, while the container is running, the CPU available to the container to the given number of CPUs (eg: 0.1).
+// It allow to simulate a dependency becoming slow under load, to test client-side timeouts and degradation handling. See UnthrottleCPU to restore the initial limit.
+func (c *Container) ThrottleCPU(ctx context.Context, cpus float64) error {
+	resources := container.Resources{}
+	if 0 != c.options.Resources.NanoCPUs {
+		resources.NanoCPUs = int64(cpus * 1e9)
+	} else {
+		resources.CPUPeriod = cpuPeriod
+		resources.CPUQuota = int64(cpus * cpuPeriod)
+	}
+	c.logger.Printf("Throttling CPU of %s to %v CPU(s)", c.Name, cpus)
+	return c.update(ctx, container.UpdateConfig{Resources: resources})
+}
+
+// UnthrottleCPU restore the CPU limit the container was created with (See Options.Resources).
+func (c *Container) UnthrottleCPU(ctx context.Context) error {
+	resources := container.Resources{}
+	if 0 != c.options.Resources.NanoCPUs {
+		resources.NanoCPUs = c.options.Resources.NanoCPUs
+	} else {
+		resources.CPUPeriod = c.options.Resources.CPUPeriod
+		resources.CPUQuota = c.options.Resources.CPUQuota
+		if 0 == resources.CPUQuota {
+			// A zero quota would leave the current one unchanged
+			resources.CPUQuota = -1
+		}
+	}
+	c.logger.Printf("Restoring CPU limit of %s", c.Name)
+	return c.update(ctx, container.UpdateConfig{Resources: resources})
+}
+
+// Pause freeze every process of the container: connections stay open, but the service stop answering, like a hung dependency. It allow to test client-side timeouts. See Unpause to resume the container.
+func (c *Container) Pause(ctx context.Context) error {
+	c.logger.Printf("Pausing container: %s", c.Name)
+	if err := c.client.ContainerPause(ctx, c.Identifier); nil != err {
+		return errors.Wrapf(err, "Pausing %s", c.Name)
+	}
+	return nil
+}
+
+// Unpause resume the processes of a paused container (See Pause).
+func (c *Container) Unpause(ctx context.Context) error {
+	c.logger.Printf("Unpausing container: %s", c.Name)
+	if err := c.client.ContainerUnpause(ctx, c.Identifier); nil != err {
+		return errors.Wrapf(err, "Unpausing %s", c.Name)
+	}
+	return nil
+}
+
+// Exec run the given command inside the container, and return its exit code and combined output (stdout and stderr).
+func (c *Container) Exec(ctx context.Context, cmd ...string) (int, []byte, error) {
+	return execute(ctx, c.client, c.Identifier, cmd)
+}
+
+// Logs return the combined logs (stdout and stderr) of the container.
+func (c *Container) Logs(ctx context.Context) ([]byte, error) {
+	return containerLogs(ctx, c.client, c.Identifier)
+}
+
+// Commit snapshot the filesystem of the container to an image with the given tag, and return the image ID. The container is paused during the commit.
+// Tests can then start from the committed image (See Options.Image), instead of repeating an expensive initialization (eg: a large seeded dataset).
+// Data stored in volumes, including the anonymous volumes declared by the image (eg: /var/lib/postgresql/data), is not part of the snapshot: such services should be configured to store their data elsewhere.
+func (c *Container) Commit(ctx context.Context, tag string) (string, error) {
+	c.logger.Printf("Committing container %s to %s", c.Name, tag)
+	committed, err := c.client.ContainerCommit(ctx, c.Identifier, types.ContainerCommitOptions{
+		Reference: tag,
+		Comment:   "Committed from " + c.Name + " by " + creator,
+		Pause:     true,
+	})
+	if nil != err {
+		return "", errors.Wrapf(err, "Committing %s", c.Name)
+	}
+	return committed.ID, nil
+}
+
+func (c *Container) update(ctx context.Context, config container.UpdateConfig) error {
+	updated, err := c.client.ContainerUpdate(ctx, c.Identifier, config)
+	if nil != err {
+		return errors.Wrapf(err, "Updating %s", c.Name)
+	}
+	for _, warning := range updated.Warnings {
+		c.logger.Warnf("%s", warning)
+	}
+	return nil
+}
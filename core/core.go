@@ -0,0 +1,44 @@
+// Package core gather the lifecycle of the containers: their options, their startup, and the handle used to act on them until they are terminated.
+// It is the stable entry point of the library: its types are aliases of the root package, so that code using docker.Options or docker.Container keep working, and both can be used interchangeably during a migration.
+package core
+
+import (
+	"context"
+
+	"github.com/normegil/docker"
+)
+
+// Options gather the needed data to create a container (See docker.Options for the fields).
+type Options = docker.Options
+
+// Container is a handle on a running container, allowing to act on it during the tests. It must be terminated once the container is not needed anymore (See Container.Terminate).
+type Container = docker.Container
+
+// ContainerInfo is the container info needed to connect and to use the underlying service.
+type ContainerInfo = docker.ContainerInfo
+
+// Session track the containers, groups, networks and volumes created through it, so that they are all removed by a single call to TerminateAll.
+type Session = docker.Session
+
+// Error give the context of an error happening during the lifecycle of a container. Use errors.As to access it.
+type Error = docker.Error
+
+// Start create a new container, and wait for it to be ready. The returned handle must be terminated once the container is not needed anymore.
+func Start(options Options) (*Container, error) {
+	return docker.Start(options)
+}
+
+// NewAll start the containers described by the given options concurrently, and return them in the same order once they are all ready. If a container cannot be started, the others are terminated.
+func NewAll(ctx context.Context, options ...Options) ([]*Container, error) {
+	return docker.NewAll(ctx, options...)
+}
+
+// FromExisting return a handle on a container started outside of this library, by name or ID. Terminate does not remove the container.
+func FromExisting(ctx context.Context, nameOrID string) (*Container, error) {
+	return docker.FromExisting(ctx, nameOrID)
+}
+
+// Reconnect return a handle on a container serialized by another process (See Container.MarshalJSON). Terminate does not remove the container.
+func Reconnect(ctx context.Context, data []byte) (*Container, error) {
+	return docker.Reconnect(ctx, data)
+}
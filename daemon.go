@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// availabilityTimeout is the maximum time given to the daemon to answer the availability probe.
+const availabilityTimeout = 2 * time.Second
+
+// Available check if the docker daemon (See DOCKER_HOST) answer a ping in a short time. The error explain why the daemon is not available.
+func Available(ctx context.Context) (bool, error) {
+	client, err := newClient(nil)
+	if nil != err {
+		return false, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, availabilityTimeout)
+	defer cancel()
+	if _, err := client.Ping(ctx); nil != err {
+		return false, withKind(ErrDaemonUnavailable, err)
+	}
+	return true, nil
+}
+
+// SkippingT is the subset of testing.TB used to skip a test (See SkipIfUnavailable).
+type SkippingT interface {
+	Helper()
+	Skipf(format string, args ...interface{})
+}
+
+// SkipIfUnavailable skip the test if the docker daemon is not available, allowing test suites to run on machines without docker.
+func SkipIfUnavailable(t SkippingT) {
+	t.Helper()
+	if available, err := Available(context.Background()); !available {
+		t.Skipf("Docker not available: %+v", err)
+	}
+}
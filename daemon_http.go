@@ -0,0 +1,61 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/sockets"
+	"github.com/pkg/errors"
+)
+
+// daemonRequest send a request to the API at the given host (See ContainerBackend), for the API features missing from the docker client used by this package. The TLS configuration of the host is used, if any (See hostTLS).
+// The path is not versioned: the latest API version of the daemon is used. A non 2xx response is returned as an error.
+func daemonRequest(ctx context.Context, host string, method string, path string, query url.Values, headers map[string]string, body io.Reader) (*http.Response, error) {
+	proto, address, basePath, err := docker.ParseHost(host)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Parsing docker host %s", host)
+	}
+
+	transport := &http.Transport{}
+	scheme := "http"
+	config, err := hostTLS(host)
+	if nil != err {
+		return nil, err
+	}
+	if nil != config {
+		transport.TLSClientConfig = config
+		scheme = "https"
+	}
+	if err := sockets.ConfigureTransport(transport, proto, address); nil != err {
+		return nil, errors.Wrapf(err, "Configuring transport for %s", host)
+	}
+	if "tcp" != proto {
+		// The address is only used to dial the socket
+		address = "docker"
+	}
+
+	target := url.URL{Scheme: scheme, Host: address, Path: strings.TrimSuffix(basePath, "/") + path, RawQuery: query.Encode()}
+	request, err := http.NewRequest(method, target.String(), body)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Creating request %s %s", method, path)
+	}
+	for key, value := range headers {
+		request.Header.Set(key, value)
+	}
+	response, err := (&http.Client{Transport: transport}).Do(request.WithContext(ctx))
+	if nil != err {
+		return nil, errors.Wrapf(err, "Requesting %s %s", method, path)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		defer response.Body.Close()
+		message, _ := ioutil.ReadAll(response.Body)
+		return nil, fmt.Errorf("Error response from daemon (%s %s): %d %s", method, path, response.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return response, nil
+}
@@ -1,52 +1,202 @@
 // Tools to init easily a temporary docker container, waiting for the service inside the container to start correctly.
-// To create the container, see the New() function.
+// To create the container, see the New() function, or Start() to get a handle on the running container.
 package docker
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/google/uuid"
 	"io"
 	"net"
 	"strconv"
 	"time"
 
 	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/container"
 	docker "github.com/docker/docker/client"
-	"github.com/docker/go-connections/nat"
-	"github.com/normegil/connectionutils"
-	"github.com/normegil/interval"
 	"github.com/pkg/errors"
 )
 
-const dockerAddress string = "127.0.0.1"
 const maxWaitTime = 5 * time.Second
 const stepWaitTime = 10 * time.Millisecond
+const probeWaitTime = 250 * time.Millisecond
 
 // Options gather the needed data to create the container.
+//
+// Deprecated: use core.Options, the same type in the stable package layout (See package core).
 type Options struct {
 	// Name of the container.
 	Name string
 	// Image is the container image name.
 	Image string
+	// Cmd override the default command of the image.
+	Cmd []string
+	// Entrypoint override the default entrypoint of the image.
+	Entrypoint []string
+	// Init run an init process (tini, bundled with the daemon) as PID 1 of the container, forwarding signals to the command and reaping zombie processes. Images spawning child processes without reaping them (eg: shell scripts, headless browsers) need it.
+	Init bool
+	// Tty allocate a pseudo-TTY to the container, for the CLIs behaving differently without a terminal (eg: buffering or colouring their output). The standard output and error are then merged in the logs (See Container.Logs, Container.FollowLogs), as StreamStdout.
+	Tty bool
+	// OpenStdin keep the standard input of the container open, even when nothing is attached to it, for commands reading their input until its end (eg: cat, REPLs).
+	OpenStdin bool
+	// Address on which the ports are published, and used to reach the container. Default to 127.0.0.1 (or ::1 on IPv6-only hosts), with ports published on every interfaces.
+	// If Address is unspecified (0.0.0.0 or ::), ports are published on every interfaces of this family and the container is reached through the matching loopback address.
+	Address net.IP
+	// DualStack publish the ports on both 127.0.0.1 and ::1. Address is then only used to reach the container.
+	DualStack bool
 	// PortBinding is a collection of port binding needed to access the container.
 	Ports []PortBinding
+	// Network is the name of the network to attach the container to, instead of the default bridge network. Containers on the same network reach each other through their aliases.
+	Network string
+	// NetworkAliases are the names of the container on Network (eg: the service name, like "db").
+	NetworkAliases []string
+	// PublishAllPorts publish every port exposed by the image on random host ports. The published ports are then added to ContainerInfo.Ports, with bindings only specifying Protocol and Internal port.
+	// When used, Ports can be empty.
+	PublishAllPorts bool
 	// EnvironmentVariables define the variables inside the container
 	EnvironmentVariables map[string]string
+	// EnvFiles are dotenv files (KEY=value lines) defining variables inside the container. Later files override earlier ones, and EnvironmentVariables override every file.
+	EnvFiles []string
+	// Secrets are written as files of SecretsDirectory, readable only by their owner, before the command of the container runs (See Secret). Unlike EnvironmentVariables, they are not visible in `docker inspect`.
+	// SecretsDirectory is a tmpfs: the secrets are never written on disk, nor committed in images (See Container.Commit). The daemon cannot copy files into a tmpfs, so the image must provide /bin/sh, cat, chown and chmod: the container is started with its command waiting for the secrets, which are written through commands executed in the container. The secrets are written again when the container is restarted by Start, Supervisor or Reuse; a RestartPolicy cannot be combined with secrets, since the daemon would restart the container without them.
+	Secrets []Secret
+	// Templates, if true, execute the values of EnvironmentVariables, Cmd and Entrypoint as Go templates (See text/template), once the host ports are selected and before the container is created, with TemplateData.
+	// It allow to configure services advertising their own address (eg: KAFKA_ADVERTISED_LISTENERS=PLAINTEXT://{{.Endpoint 9092}}), or referencing the other containers of their network (See TemplateData.Peers).
+	Templates bool
+	// Configure, if specified, start the container in two phases, for services which must advertise their host ports, including the ones assigned by the daemon (See PublishAllPorts): the container is started with its command waiting for a configuration, Configure is called with the started container, and the command of the container is then run with the returned additional environment variables.
+	// Configure can also copy generated configuration files in the container (See Container.CopyFiles). The image must provide /bin/sh, and the configuration is kept when the container is restarted (host ports assigned by the daemon can then change).
+	Configure func(ctx context.Context, c *Container) (map[string]string, error)
+	// ProxyEnvironment, if ProxyEnabled, propagate the proxy settings of the host (HTTP_PROXY, HTTPS_PROXY, FTP_PROXY, NO_PROXY) into the container, for services downloading artifacts at startup behind a corporate proxy. EnvironmentVariables take precedence.
+	// Default to Config.ProxyEnvironment, which ProxyDisabled override for this container. Proxies listening on the host loopback are not reachable from the container, and must be specified with an address reachable from the containers.
+	ProxyEnvironment ProxyPolicy
+	// Labels to add to the container. Labels managed by this package (See LabelSession) are always added.
+	Labels map[string]string
 	// If specified, this logger will be used to log messages during initialisation of the docker (And at closing/removing time).
 	Logger Logger
+	// LogLevel is the minimum level of the messages sent to Logger. Default to LevelDebug (every message).
+	LogLevel Level
+	// Resources limit the resources (memory, CPU, processes) the container can use.
+	Resources Resources
+	// Ulimits override the default resource limits of the container processes (eg: nofile for Elasticsearch).
+	Ulimits []Ulimit
+	// Sysctls set namespaced kernel parameters in the container (eg: net.ipv4.ip_forward).
+	Sysctls map[string]string
+	// Devices to map from the host into the container (eg: /dev/fuse).
+	Devices []Device
+	// GPUs request access to NVIDIA GPUs: "all", or a comma separated list of GPU indexes or UUIDs.
+	// The docker API version used by this package does not support device requests: GPUs are made available through the nvidia runtime, which must be installed on the host.
+	GPUs string
+	// Runtime used to run the container (eg: nvidia, runsc). Default to the daemon default runtime, or nvidia if GPUs are requested.
+	Runtime string
+	// Isolation is the isolation technology of Windows containers: process or hyperv. Default to the daemon default (process on Windows Server, hyperv on Windows 10/11).
+	Isolation string
+	// Privileged give extended privileges to the container (eg: Docker-in-Docker, systemd based images).
+	Privileged bool
+	// CapAdd and CapDrop add or remove kernel capabilities (eg: NET_ADMIN, SYS_ADMIN, BPF).
+	CapAdd  []string
+	CapDrop []string
+	// SecurityOpt customize the security profiles of the container (eg: seccomp=unconfined, apparmor=unconfined).
+	SecurityOpt []string
+	// GroupAdd add groups the container process will run as.
+	GroupAdd []string
+	// CgroupParent is the cgroup under which the cgroup of the container is created, to account the resources used by the tests in a cgroup managed by the CI (eg: /ci/job-42).
+	CgroupParent string
+	// PidMode and IpcMode share the PID or IPC namespace of the host ("host") or of another container ("container:<name or ID>"), instead of private namespaces (eg: to debug or profile the processes of another container).
+	PidMode string
+	IpcMode string
+	// UsernsMode is the user namespace of the container: "host" disable the user namespace remapping of daemons started with --userns-remap, which some images need (eg: to bind-mount host files owned by the host users).
+	UsernsMode string
+	// Binds mount host paths inside the container.
+	Binds []Bind
+	// Reload, if specified, notify the container when a bind-mounted path is modified on the host (See Reload).
+	Reload *Reload
+	// TmpfsMounts mount tmpfs (in memory) filesystems in the container, indexed by path. Values are the mount options (eg: "rw,size=512m"). See also TmpfsDataDirectory.
+	TmpfsMounts map[string]string
+	// ReadonlyRootfs mount the root filesystem of the container read-only, like hardened production deployments. The paths the image need writable (/tmp, /run, the data directory of well-known images, See WritablePaths) are mounted on tmpfs, unless Binds or TmpfsMounts already mount them.
+	// Other paths written by the service must be added to TmpfsMounts or Binds.
+	ReadonlyRootfs bool
+	// RestartPolicy define how the daemon restart the container when it exits. Default to never restarting it.
+	RestartPolicy RestartPolicy
+	// LogConfig configure how the daemon store the logs of the container (eg: capped json-file logs, or journald on long-running shared hosts).
+	// The logs can only be read back (See Container.Logs, ForLog) with the json-file, local and journald drivers.
+	LogConfig LogConfig
+	// AutoRemove let the daemon remove the container as soon as it exits, even if the test process is killed before removing it. Cannot be used with a RestartPolicy.
+	AutoRemove bool
+	// StopSignal is the signal sent to the container main process to stop it (eg: SIGINT). Default to the image stop signal (usually SIGTERM).
+	StopSignal string
+	// StopTimeout is the time given to the container to stop gracefully, before being killed. If StopTimeout or StopSignal are specified, the container is stopped before being removed (See Container.Terminate).
+	// Some databases corrupt their volumes when only force-removed, which matter when the data is reused or snapshotted.
+	StopTimeout time.Duration
+	// Files to copy inside the container, before starting it.
+	Files []File
+	// Reuse, if true, attach to the container previously created with the same options (by this or another test binary) instead of creating a new one. The container is not removed by Container.Terminate, allowing the next tests to reuse it.
+	// Containers are matched by a key computed from the options (image, environment, ports, mounts, ...), and Name must be specified. Ports are reused from the existing container.
+	// Reusable containers don't belong to the session (See LabelSession): they are not removed by CleanupSession, the reaper or CleanupOrphans, and must be removed explicitly once not needed anymore (eg: docker rm -f).
+	Reuse bool
+	// StartupTimeout is the maximum time to wait for the container to be ready. Default to 5 seconds.
+	StartupTimeout time.Duration
+	// FromDockerfile, if specified, build the image of the container from a Dockerfile, instead of pulling Image. Image is then ignored.
+	FromDockerfile *FromDockerfile
+	// ImageDigest, if specified, is the digest (sha256:...) the image must have, to ensure tests always run against the same image build. Image can also directly be a digest reference (repository@sha256:...).
+	ImageDigest string
+	// Platform of the image to pull (eg: linux/amd64), when the image has no build for the host platform (eg: ARM hosts). The daemon must support emulation for the platform (eg: binfmt/QEMU).
+	Platform string
+	// RegistryAuth are the credentials used to pull the image. Default to the credentials of the docker CLI configuration (~/.docker/config.json), including credential helpers.
+	RegistryAuth *RegistryAuth
+	// ImageTarPath, if specified, is an archive (created by `docker save`) containing the image. It is loaded instead of pulling the image, when the image is not available locally (eg: air-gapped CI runners).
+	ImageTarPath string
+	// PullPolicy define when the image is pulled. Default to PullIfNotPresent.
+	PullPolicy PullPolicy
+	// PullProgress, if specified, is called for every progress event received while pulling the image, so that long pulls can show their advancement.
+	PullProgress func(event PullEvent)
+	// Retries configure how the different operations (pull, startup, readiness checks, ...) are retried.
+	Retries Retries
+	// WaitStrategy define how to know that the service inside the container is ready. Default to waiting for the first port of Ports to accept connections.
+	WaitStrategy WaitStrategy
+	// DryRun, if true, only log the `docker run` command and docker-compose service equivalent to the options, without creating anything. New then return ErrDryRun.
+	DryRun bool
+	// Progress, if specified, track the startup of the container with the other containers sharing the same Progress, and periodically report their aggregated state.
+	Progress *Progress
+	// If specified, the container will be supervised until it is removed (See Supervisor).
+	Supervisor *Supervisor
+	// Hooks are called at the phases of the container lifecycle (See Hooks).
+	Hooks Hooks
+	// Tracer, if specified, record the startup of the container as spans (See Tracer), to see in the CI traces where the time of the integration tests goes.
+	Tracer Tracer
+	// Metrics, if specified, receive the durations of the pulls and startups, and the readiness failures (See Metrics, ExpvarMetrics).
+	Metrics Metrics
+	// Backend is the container engine on which the container is created (eg: PodmanBackend{}, or DockerBackend{Context: "remote"} to use a named docker context). Default to DetectBackend().
+	Backend ContainerBackend
+	// Client, if specified, is the docker client used to create the container, instead of a new client for Backend. It allow to use a pre-configured client (custom TLS or HTTP transport, proxies, ...), and to share one client between many containers.
+	// The client is not closed by this package. Requests not supported by the client (See Platform, FromDockerfile.Target) are still sent to Backend.
+	Client *docker.Client
+	// Sidecars are containers sharing the network namespace of this container (eg: toxiproxy, a metrics exporter), reachable on localhost from it. They are started once this container is ready, and removed with it (See Container.Terminate).
+	// Their ports must be declared in Ports of this container, and their WaitStrategy is checked against the address and ports of this container. Default to waiting for the sidecar to be running.
+	Sidecars []Options
 }
 
+// Protocols supported by PortBinding.
+const (
+	ProtocolTCP  = "tcp"
+	ProtocolUDP  = "udp"
+	ProtocolSCTP = "sctp"
+)
+
 // PortBinding should follow this structure.
+// The same internal port can be published several times, on different host interfaces, by specifying multiple PortBindings.
 type PortBinding struct {
-	// Protocol can be TCP,UDP,...
+	// Protocol can be tcp, udp or sctp (See ProtocolTCP, ProtocolUDP and ProtocolSCTP).
+	// Only TCP ports can be used to check the readiness of the container: for other protocols, a WaitStrategy should be specified (or ForRunning() to skip readiness checks).
 	Protocol string
 	// Internal port to bind to.
 	Internal int
 	// ExternalInterval define the range of possible external port that can be mapped to the specified internal port.
 	ExternalInterval string
+	// InternalEnd, if greater than Internal, publish the whole range of internal ports [Internal, InternalEnd] (eg: passive FTP ports).
+	// The host ports are then selected as a contiguous block in ExternalInterval, starting at the port returned in ContainerInfo.Ports (See ContainerInfo.HostPort).
+	InternalEnd int
+	// HostIP is the host interface on which the port is published (eg: 127.0.0.1 to keep it private on a shared machine, 0.0.0.0 to reach it from another container).
+	// If empty, the addresses defined by Options.Address and Options.DualStack are used.
+	HostIP string
 }
 
 // ContainerInfo return the container info needed to connect and to use the underlying service.
@@ -57,219 +207,479 @@ type ContainerInfo struct {
 	Address net.IP
 	// Ports will return the selected external ports, associated to PortBindings specified as Inputs at the creation of the container.
 	Ports map[PortBinding]int
+	// Image describe the image the container was created from.
+	Image ImageInfo
+	// Direct is true when the container is reached directly on its own address and internal ports, instead of the published ports. It happen when the test process itself run inside a container of the same daemon, where the host loopback is not reachable.
+	Direct bool
+}
+
+// HostPort return the host port on which the given internal port is published, for a binding publishing a range of ports. Return 0 if the port is not part of the binding.
+func (i ContainerInfo) HostPort(binding PortBinding, internal int) int {
+	first, ok := i.Ports[binding]
+	if !ok || internal < binding.Internal || internal > binding.lastInternal() {
+		return 0
+	}
+	return first + internal - binding.Internal
+}
+
+// Port return the port to use, with Address, to reach the given port binding: the host port, or the internal port if the container is reached directly (See Direct).
+func (i ContainerInfo) Port(binding PortBinding) int {
+	if i.Direct {
+		return binding.Internal
+	}
+	return i.Ports[binding]
+}
+
+// Endpoint return the "host:port" address to use to reach the given port binding.
+func (i ContainerInfo) Endpoint(binding PortBinding) string {
+	address := i.Address
+	if ip := net.ParseIP(binding.HostIP); nil != ip && !ip.IsUnspecified() && !i.Direct {
+		address = ip
+	}
+	return net.JoinHostPort(address.String(), strconv.Itoa(i.Port(binding)))
 }
 
 // Create a new container. The function will return some infos on the created container and a function to call to close and remove the container.
+//
+// Deprecated: use core.Start, which return a handle allowing to act on the container during the tests, and Container.Terminate to remove it.
 func New(options Options) (*ContainerInfo, func() error, error) {
-	var l Logger = &defaultLogger{}
-	if nil != options.Logger {
-		l = options.Logger
+	c, err := Start(options)
+	if nil != err {
+		return nil, nil, err
 	}
+	return &c.ContainerInfo, func() error {
+		return c.Terminate(context.Background())
+	}, nil
+}
+
+// Start create a new container, and wait for it to be ready. The returned handle allow to act on the container during the tests, and must be terminated once the container is not needed anymore.
+func Start(options Options) (*Container, error) {
+	start := time.Now()
+	l := newLogger(options)
 
-	l.Printf("New docker client from environment")
-	client, err := docker.NewEnvClient()
+	loaded, err := LoadConfig()
 	if nil != err {
-		return nil, nil, errors.Wrap(err, "MongoDB: Could not create docker client")
+		return nil, lifecycleError(PhaseOptions, options.Name, options, err)
+	}
+	if loaded.Reaper && reaperImage != options.Image && !options.DryRun {
+		if err := ensureReaper(options.Logger); nil != err {
+			return nil, lifecycleError(PhaseOptions, options.Name, options, err)
+		}
+	}
+	requested := options.Image
+	options = withConfig(options, loaded)
+	if options, err = withEnvFiles(options); nil != err {
+		return nil, lifecycleError(PhaseOptions, options.Name, options, err)
+	}
+	if requested != options.Image {
+		l.Printf("Image %s rewritten to %s", requested, options.Image)
 	}
 
-	if err = pullImage(client, options); err != nil {
-		return nil, nil, errors.Wrap(err, "Downloading image: "+options.Image)
+	if options.DryRun {
+		l.Printf("Dry run, equivalent command: %s", DockerRunCommand(options))
+		l.Printf("Dry run, equivalent docker-compose service:\n%s", ComposeService(options))
+		return nil, ErrDryRun
 	}
 
-	ip := net.ParseIP(dockerAddress)
+	if nil != options.Hooks.PreCreate {
+		l.Debugf("Running PreCreate hook of %s", options.Name)
+		if err := options.Hooks.PreCreate(context.Background(), &options); nil != err {
+			return nil, lifecycleError(PhaseOptions, options.Name, options, errors.Wrap(err, "PreCreate hook"))
+		}
+	}
 	if err := checkOptions(options); err != nil {
-		return nil, nil, errors.New("Docker instance cannot be used without a external port")
+		return nil, lifecycleError(PhaseOptions, options.Name, options, errors.Wrap(err, "Invalid options"))
 	}
 
-	suffix, err := uuid.NewRandom()
+	tracker := trackStartup(options)
+	var ready *ContainerInfo
+	defer func() {
+		tracker.finished(ready)
+	}()
+
+	client, err := clientOf(options, l)
 	if nil != err {
-		return nil, nil, errors.Wrapf(err, "generating docker suffix for %s", options.Name)
+		return nil, lifecycleError(PhaseClient, options.Name, options, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client")))
 	}
 
-	containerName := options.Name + "-" + suffix.String()
-	dockerPorts, err := selectPorts(ip, options.Ports)
+	if nil != options.FromDockerfile {
+		tracker.waiting("building image")
+		span := tracker.phase(SpanBuild, map[string]string{AttributeImage: options.Image})
+		options.Image, err = buildImage(client, options, *options.FromDockerfile, l.with(Fields{"phase": PhaseBuild}))
+		span.End(err)
+		if nil != err {
+			return nil, lifecycleError(PhaseBuild, options.Name, options, err)
+		}
+	} else {
+		tracker.waiting("pulling image " + options.Image)
+		span := tracker.phase(SpanPull, map[string]string{AttributeImage: options.Image})
+		err = pullImage(context.Background(), client, options)
+		span.End(err)
+		if err != nil {
+			if docker.IsErrConnectionFailed(err) {
+				return nil, lifecycleError(PhaseClient, options.Name, options, withKind(ErrDaemonUnavailable, err))
+			}
+			return nil, lifecycleError(PhasePull, options.Name, options, withKind(ErrImagePull, err))
+		}
+	}
+	image, err := inspectImage(client, options.Image)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "Selecting ports")
+		return nil, lifecycleError(PhasePull, options.Name, options, err)
+	}
+	if "" != options.ImageDigest {
+		if err := image.verifyDigest(options.ImageDigest); nil != err {
+			return nil, lifecycleError(PhasePull, options.Name, options, withKind(ErrDigestMismatch, err))
+		}
 	}
-	portBindings := toDockerPortBindings(ip, dockerPorts)
-	l.Printf("Port Bindings: %+v", portBindings)
+	if err := checkPlatformOptions(options, *image); nil != err {
+		return nil, lifecycleError(PhaseOptions, options.Name, options, errors.Wrap(err, "Invalid options"))
+	}
+	l.Printf("Using image %s (ID: %s, Digest: %s)", options.Image, image.ID, image.Digest)
 
-	varDefinitions := make([]string, 0)
-	for key, value := range options.EnvironmentVariables {
-		varDefinitions = append(varDefinitions, key+"="+value)
+	strategy := waitStrategy(options)
+	retries := withDefaultRetries(options)
+	var info *ContainerInfo
+	var containerName string
+	err = retries.Startup.Retry(context.Background(), func() error {
+		var err error
+		if options.Reuse {
+			info, containerName, err = startReusableContainer(client, options, l, *image, strategy, tracker)
+		} else {
+			info, containerName, err = startContainer(client, options, l, *image, strategy, tracker)
+		}
+		if nil != err {
+			l.Warnf("Container startup failed: %+v", err)
+		}
+		return err
+	}, nil)
+	if nil != err {
+		l.Printf("Reproduce the container with: %s", DockerRunCommand(options))
+		return nil, err
+	}
+	l = l.with(Fields{"container": containerName})
+	l.with(Fields{"duration": time.Since(start)}).Printf("Container started: " + containerName)
+	ready = info
+
+	c := &Container{
+		ContainerInfo: *info,
+		Name:          containerName,
+		client:        client,
+		logger:        l,
+		options:       options,
+		strategy:      strategy,
+		timings:       tracker.timings(),
+	}
+	if 0 != len(options.Sidecars) {
+		if err := c.startSidecars(); nil != err {
+			if terminateErr := c.Terminate(context.Background()); nil != terminateErr {
+				l.Errorf("Could not terminate %s: %+v", containerName, terminateErr)
+			}
+			return nil, err
+		}
+	}
+	c.startWatchers()
+	if err := runHook(context.Background(), "PostStart", options.Hooks.PostStart, c); nil != err {
+		if terminateErr := c.Terminate(context.Background()); nil != terminateErr {
+			l.Errorf("Could not terminate %s: %+v", containerName, terminateErr)
+		}
+		return nil, lifecycleError(PhaseStart, containerName, options, err)
+	}
+	return c, nil
+}
+
+// startContainer create and start a container, waiting for it to be ready. If the container was created but is not ready, it is removed.
+func startContainer(client *docker.Client, options Options, l *eventLogger, image ImageInfo, strategy WaitStrategy, tracker *startupTracker) (*ContainerInfo, string, error) {
+	bindAddresses, ip := hostAddresses(options)
+	containerName, err := newContainerName(options)
+	if nil != err {
+		return nil, "", lifecycleError(PhaseCreate, options.Name, options, err)
+	}
+	l = l.with(Fields{"container": containerName})
+	dockerPorts, err := selectPorts(checkedAddresses(bindAddresses, ip), options.Ports)
+	if err != nil {
+		return nil, containerName, lifecycleError(PhasePorts, containerName, options, withKind(ErrPortSelection, err))
+	}
+	portBindings := toDockerPortBindings(bindAddresses, dockerPorts)
+	l.Debugf("Port Bindings: %+v", portBindings)
+	if options.Templates {
+		data, err := templateData(client, options, containerName, ip, dockerPorts)
+		if nil == err {
+			options, err = expandTemplates(options, data)
+		}
+		if nil != err {
+			return nil, containerName, lifecycleError(PhaseOptions, containerName, options, err)
+		}
 	}
 
-	l.Printf("Creating container: %+v", containerName)
+	l.with(Fields{"phase": PhaseCreate}).Printf("Creating container: %+v", containerName)
+	tracker.waiting("creating container")
 	ctx := context.Background()
-	containerInfo, err := client.ContainerCreate(ctx, &container.Config{
-		Image:        options.Image,
-		ExposedPorts: toExposedPorts(options.Ports),
-		Env:          varDefinitions,
-	}, &container.HostConfig{
-		PortBindings: portBindings,
-	}, nil, containerName)
+	span := tracker.phase(SpanCreate, map[string]string{AttributeContainerName: containerName, AttributeImage: options.Image})
+	containerInfo, err := client.ContainerCreate(ctx, containerConfig(options, image), hostConfig(options, portBindings), networkingConfig(options), containerName)
+	if nil == err {
+		span.SetAttributes(map[string]string{AttributeContainerID: containerInfo.ID})
+	}
+	span.End(err)
 	if nil != err {
-		return nil, nil, errors.Wrap(err, "Could not create container ("+containerName+")")
+		return nil, containerName, lifecycleError(PhaseCreate, containerName, options, errors.Wrap(err, "Could not create container"))
 	}
 	for _, warning := range containerInfo.Warnings {
-		l.Printf(warning)
+		l.Warnf("%s", warning)
 	}
 
-	l.Printf("Starting container: " + containerName)
 	containerID := containerInfo.ID
-	if err := client.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); nil != err {
-		return nil, nil, errors.Wrap(err, "Could not start container ("+containerName+")")
-	}
-
-	l.Printf("Waiting for container: " + containerName)
-	reachablePorts := dockerPorts[options.Ports[0]]
-	if err := waitContainer(client, containerID, dockerAddress+":"+strconv.Itoa(reachablePorts), maxWaitTime); nil != err {
-		return nil, nil, errors.Wrap(err, "Container not started withing time limit")
-	}
-	l.Printf("Container started: " + containerName)
-
-	return &ContainerInfo{
-			Identifier: containerID,
-			Address:    ip,
-			Ports:      dockerPorts,
-		}, func() error {
-			l.Printf("Removing container: " + containerName)
-			ctx := context.Background()
-			if err := client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{Force: true}); nil != err {
-				return errors.Wrap(err, "MongoDB: Could not remove "+containerName)
-			}
-			return nil
-		}, nil
+	info := &ContainerInfo{
+		Identifier: containerID,
+		Address:    ip,
+		Ports:      dockerPorts,
+		Image:      image,
+	}
+	created := &Container{ContainerInfo: *info, Name: containerName, client: client, logger: l, options: options, strategy: strategy}
+	err = runHook(ctx, "PostCreate", options.Hooks.PostCreate, created)
+	if nil != err {
+		err = lifecycleError(PhaseCreate, containerName, options, err)
+	} else {
+		err = prepareContainer(client, options, l, info, containerName, strategy, tracker)
+	}
+	if nil != err {
+		l.Printf("Removing container: " + containerName)
+		if removeErr := client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{Force: true}); nil != removeErr {
+			l.Errorf("Could not remove %s: %+v", containerName, removeErr)
+		}
+		return nil, containerName, err
+	}
+	return info, containerName, nil
 }
 
-func pullImage(client *docker.Client, options Options) error {
-	var l Logger = &defaultLogger{}
-	if nil != options.Logger {
-		l = options.Logger
+// prepareContainer start a created container, and wait for it to be ready.
+func prepareContainer(client *docker.Client, options Options, l *eventLogger, info *ContainerInfo, containerName string, strategy WaitStrategy, tracker *startupTracker) error {
+	if 0 != len(options.Files) {
+		l.Printf("Copying files in container: " + containerName)
+		if err := copyFiles(client, info.Identifier, options.Files); nil != err {
+			return lifecycleError(PhaseCreate, containerName, options, errors.Wrap(err, "Could not copy files in container"))
+		}
 	}
 
-	l.Printf("Listing available images")
-	images, err := client.ImageList(context.Background(), types.ImageListOptions{})
-	if err != nil {
-		return errors.Wrap(err, "Listing images")
+	l.with(Fields{"phase": PhaseStart}).Printf("Starting container: " + containerName)
+	tracker.waiting("starting container")
+	span := tracker.phase(SpanStart, map[string]string{AttributeContainerID: info.Identifier})
+	err := client.ContainerStart(context.Background(), info.Identifier, types.ContainerStartOptions{})
+	span.End(err)
+	if nil != err {
+		return lifecycleError(PhaseStart, containerName, options, errors.Wrap(err, "Could not start container"))
+	}
+	if 0 != len(options.Secrets) {
+		l.Printf("Writing secrets in container: " + containerName)
+		if err := writeSecrets(context.Background(), client, info.Identifier, options.Secrets); nil != err {
+			return lifecycleError(PhaseStart, containerName, options, err)
+		}
+	}
+
+	if options.PublishAllPorts {
+		if err := addPublishedPorts(client, info.Identifier, info.Ports); nil != err {
+			return lifecycleError(PhaseStart, containerName, options, errors.Wrap(err, "Could not list published ports"))
+		}
+		l.Debugf("Published ports: %+v", info.Ports)
+	}
+
+	if err := reachFromContainer(client, options, info); nil != err {
+		return lifecycleError(PhaseStart, containerName, options, errors.Wrap(err, "Could not find container address"))
+	}
+	if info.Direct {
+		l.Printf("Tests running inside a container, reaching %s directly on %s", containerName, info.Address)
+	}
+	if nil != options.Configure {
+		started := &Container{ContainerInfo: *info, Name: containerName, client: client, logger: l, options: options, strategy: strategy}
+		if err := configure(context.Background(), started); nil != err {
+			return lifecycleError(PhaseStart, containerName, options, err)
+		}
+	}
+
+	l.with(Fields{"phase": PhaseWait}).Printf("Waiting for container: " + containerName)
+	peak := followMemoryPeak(client, info.Identifier, options)
+	if err := waitContainer(client, *info, strategy, startupTimeout(options), withDefaultRetries(options), tracker); nil != err {
+		return lifecycleError(PhaseWait, containerName, options, startupFailure(client, info.Identifier, peak(), errors.Wrap(err, "Container not started within time limit")))
 	}
-	for _, image := range images {
-		l.Printf("Available: %s (Searched:%s)", image.RepoTags, options.Image)
-		for _, tag := range image.RepoTags {
-			if tag == options.Image {
+	peak()
+	return nil
+}
+
+func pullImage(ctx context.Context, client *docker.Client, options Options) error {
+	l := newLogger(options).with(Fields{"phase": PhasePull})
+
+	if PullAlways != options.PullPolicy {
+		l.Debugf("Listing available images")
+		images, err := client.ImageList(ctx, types.ImageListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "Listing images")
+		}
+		for _, image := range images {
+			l.Debugf("Available: %s %s (Searched:%s)", image.RepoTags, image.RepoDigests, options.Image)
+			if !localImageMatch(options.Image, image) {
+				continue
+			}
+			if "" == options.Platform {
 				return nil
 			}
+			match, err := platformMatch(ctx, client, options.Image, options.Platform)
+			if nil != err {
+				return err
+			}
+			if match {
+				return nil
+			}
+			l.Printf("Local image %s is not built for %s", options.Image, options.Platform)
+		}
+		if "" != options.ImageTarPath {
+			return loadImageTar(ctx, client, options, l)
+		}
+		if PullNever == options.PullPolicy {
+			return fmt.Errorf("Image %s not available locally, and pull policy is %s", options.Image, PullNever)
 		}
 	}
 
+	auth, err := registryAuth(options)
+	if nil != err {
+		return err
+	}
 	l.Printf("Pulling %s", options.Image)
-	events, err := client.ImagePull(context.Background(), options.Image, types.ImagePullOptions{})
+	start := time.Now()
+	err = withDefaultRetries(options).Pull.Retry(ctx, func() error {
+		err := pull(ctx, client, options, auth, l)
+		if nil != err {
+			l.Warnf("Pulling %s failed: %+v", options.Image, err)
+		}
+		return err
+	}, transientPullError)
+	if nil != options.Metrics {
+		options.Metrics.ImagePulled(options.Image, time.Since(start), nil != err)
+	}
+	if nil != err {
+		return err
+	}
+	l.Printf("Image %s pulled", options.Image)
+	return nil
+}
+
+// pull download the image of the options, logging the progress of every layer at the debug level, and sending every progress event to the given callback (if not nil).
+func pull(ctx context.Context, client *docker.Client, options Options, auth string, l *eventLogger) error {
+	reference := options.Image
+	progress := options.PullProgress
+	var events io.ReadCloser
+	var err error
+	if "" == options.Platform {
+		events, err = client.ImagePull(ctx, reference, types.ImagePullOptions{RegistryAuth: auth})
+	} else {
+		events, err = pullPlatform(ctx, options, auth)
+	}
 	if err != nil {
-		return errors.Wrap(err, "Pulling image: "+options.Image)
+		return errors.Wrap(err, "Pulling image: "+reference)
 	}
+	defer events.Close()
 
 	stream := json.NewDecoder(events)
 
 	type Event struct {
+		ID             string `json:"id"`
 		Status         string `json:"status"`
 		Error          string `json:"error"`
 		Progress       string `json:"progress"`
 		ProgressDetail struct {
-			Current int `json:"current"`
-			Total   int `json:"total"`
+			Current int64 `json:"current"`
+			Total   int64 `json:"total"`
 		} `json:"progressDetail"`
 	}
 	var event Event
+	statuses := make(map[string]string)
 
 	for {
+		event = Event{}
 		if err := stream.Decode(&event); nil != err {
 			if io.EOF == err {
 				break
 			}
 
-			return errors.Wrapf(err, "Pulling %s (Error decoding json stream)", options.Image)
+			return errors.Wrapf(err, "Pulling %s (Error decoding json stream)", reference)
+		}
+		if "" != event.Error {
+			return fmt.Errorf("Pulling %s: %s", reference, event.Error)
+		}
+		if nil != progress {
+			progress(PullEvent{
+				Image:   reference,
+				Layer:   event.ID,
+				Status:  event.Status,
+				Current: event.ProgressDetail.Current,
+				Total:   event.ProgressDetail.Total,
+			})
+		}
+		if statuses[event.ID] != event.Status {
+			statuses[event.ID] = event.Status
+			l.Debugf("Pulling %s: %s %s", reference, event.ID, event.Status)
 		}
 	}
-	l.Printf("Image %s pulled", options.Image)
 	return nil
 }
 
-func checkOptions(options Options) error {
-	if nil == options.Ports || 0 == len(options.Ports) {
-		return errors.New("At least one port should be open for external communication")
+func withDefaultRetries(options Options) Retries {
+	return Retries{
+		Pull:      options.Retries.Pull.withDefaults(defaultPullBackoff),
+		Readiness: options.Retries.Readiness.withDefaults(defaultReadinessBackoff),
+		Startup:   options.Retries.Startup.withDefaults(defaultStartupBackoff),
+		Daemon:    options.Retries.Daemon.withDefaults(defaultDaemonBackoff),
 	}
-	return nil
 }
 
-func toExposedPorts(ports []PortBinding) nat.PortSet {
-	exposed := make(map[nat.Port]struct{})
-	for _, binding := range ports {
-		exposed[nat.Port(strconv.Itoa(binding.Internal)+"/"+binding.Protocol)] = struct{}{}
+func startupTimeout(options Options) time.Duration {
+	if 0 < options.StartupTimeout {
+		return options.StartupTimeout
 	}
-	return nat.PortSet(exposed)
+	return maxWaitTime
 }
 
-func selectPorts(address net.IP, possiblePorts []PortBinding) (map[PortBinding]int, error) {
-	used := make([]int, 0)
-	toReturn := make(map[PortBinding]int)
-	for _, binding := range possiblePorts {
-		interval, err := interval.ParseIntervalInteger(binding.ExternalInterval)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Parsing %s", binding.ExternalInterval)
-		}
-		selected := connectionutils.SelectPortExcluding(address, *interval, used)
-		toReturn[binding] = selected.Port
+func checkOptions(options Options) error {
+	if (nil == options.Ports || 0 == len(options.Ports)) && !options.PublishAllPorts {
+		return errors.New("At least one port should be open for external communication")
 	}
-	return toReturn, nil
-}
-
-func toDockerPortBindings(address net.IP, ports map[PortBinding]int) map[nat.Port][]nat.PortBinding {
-	toReturn := make(map[nat.Port][]nat.PortBinding)
-	for binding, selectedPort := range ports {
-		toReturn[nat.Port(strconv.Itoa(binding.Internal)+"/"+binding.Protocol)] = []nat.PortBinding{
-			{
-				//HostIP:   "0.0.0.0",
-				HostPort: strconv.Itoa(selectedPort), // + "/" + binding.Protocol,
-			},
-		}
+	if 0 != len(options.Secrets) && "" != options.RestartPolicy.Name && "no" != options.RestartPolicy.Name {
+		return errors.New("Secrets cannot be used with a restart policy: the daemon would restart the container without its secrets (See Supervisor to restart it)")
 	}
-	return toReturn
-}
-
-func waitContainer(client *docker.Client, containerID string, hostport string, maxWait time.Duration) error {
-	if err := waitStarted(client, containerID, maxWait); nil != err {
-		return err
+	if options.AutoRemove && "" != options.RestartPolicy.Name && "no" != options.RestartPolicy.Name {
+		return errors.New("AutoRemove cannot be used with a restart policy")
 	}
-	if err := waitReachable(hostport, maxWait); nil != err {
-		return err
+	if options.Reuse && "" == options.Name {
+		return errors.New("Reuse require a Name, to identify the reused container")
 	}
-	return nil
-}
-
-func waitReachable(hostport string, maxWait time.Duration) error {
-	done := time.Now().Add(maxWait)
-	for time.Now().Before(done) {
-		c, err := net.Dial("tcp", hostport)
-		if nil == err {
-			return c.Close()
+	if options.Reuse && 0 != len(options.Sidecars) {
+		return errors.New("Reuse cannot be used with sidecars")
+	}
+	if nil != options.Configure && options.ReadonlyRootfs {
+		return errors.New("Configure cannot be used with a read-only root filesystem, in which the configuration cannot be written")
+	}
+	for i, sidecar := range options.Sidecars {
+		if err := checkSidecarOptions(sidecar); nil != err {
+			return errors.Wrapf(err, "Sidecar #%d (%s)", i, sidecar.Image)
 		}
-		time.Sleep(stepWaitTime)
 	}
-	return fmt.Errorf("Could not reach %s {WaitingTime: %+v}", hostport, maxWait)
-}
-
-func waitStarted(client *docker.Client, containerID string, maxWait time.Duration) error {
-	done := time.Now().Add(maxWait)
-	for time.Now().Before(done) {
-		ctx := context.Background()
-		c, err := client.ContainerInspect(ctx, containerID)
-		if err != nil {
-			break
+	switch options.PullPolicy {
+	case "", PullIfNotPresent, PullAlways, PullNever:
+	default:
+		return fmt.Errorf("Unsupported pull policy: %s", options.PullPolicy)
+	}
+	switch options.ProxyEnvironment {
+	case "", ProxyEnabled, ProxyDisabled:
+	default:
+		return fmt.Errorf("Unsupported proxy policy: %s", options.ProxyEnvironment)
+	}
+	for _, binding := range options.Ports {
+		switch binding.protocol() {
+		case ProtocolTCP, ProtocolUDP, ProtocolSCTP:
+		default:
+			return fmt.Errorf("Unsupported protocol for port %d: %s", binding.Internal, binding.Protocol)
 		}
-		if c.State.Running {
-			return nil
+		if "" != binding.HostIP && nil == net.ParseIP(binding.HostIP) {
+			return fmt.Errorf("Invalid host IP for port %d: %s", binding.Internal, binding.HostIP)
 		}
-		time.Sleep(stepWaitTime)
 	}
-	return fmt.Errorf("Container not started: %s {WaitingTime: %+v}", containerID, maxWait)
+	return nil
 }
@@ -0,0 +1,190 @@
+package docker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/fake"
+)
+
+const testImage = "alpine:3"
+
+var testPort = docker.PortBinding{Protocol: docker.ProtocolTCP, Internal: 80, ExternalInterval: "[20000;30000]"}
+
+// newBackend start a fake backend, providing the test image. It must be closed at the end of the test.
+func newBackend(t *testing.T) *fake.Backend {
+	t.Helper()
+	backend, err := fake.New()
+	if nil != err {
+		t.Fatalf("Starting fake backend: %+v", err)
+	}
+	backend.AddImage(testImage)
+	return backend
+}
+
+func testOptions(backend *fake.Backend) docker.Options {
+	return docker.Options{
+		Name:           "test",
+		Image:          testImage,
+		Ports:          []docker.PortBinding{testPort},
+		Backend:        backend,
+		StartupTimeout: 5 * time.Second,
+	}
+}
+
+func TestStartTerminate(t *testing.T) {
+	backend := newBackend(t)
+	defer backend.Close()
+	options := testOptions(backend)
+	options.EnvironmentVariables = map[string]string{"KEY": "value"}
+
+	c, err := docker.Start(options)
+	if nil != err {
+		t.Fatalf("Start: %+v", err)
+	}
+	containers := backend.Containers()
+	if 1 != len(containers) {
+		t.Fatalf("Expected 1 container, got %d", len(containers))
+	}
+	created := containers[0]
+	if !created.Running {
+		t.Errorf("Container %s is not running", created.Name)
+	}
+	if c.Identifier != created.ID {
+		t.Errorf("Identifier: expected %s, got %s", created.ID, c.Identifier)
+	}
+	if port := c.Ports[testPort]; created.Ports["80/tcp"] != port || 0 == port {
+		t.Errorf("Host port: expected %d, got %d", created.Ports["80/tcp"], port)
+	}
+	if !contains(created.Env, "KEY=value") {
+		t.Errorf("Environment variable KEY not defined: %v", created.Env)
+	}
+	if _, ok := created.Labels[docker.LabelSession]; !ok {
+		t.Errorf("Label %s not defined: %v", docker.LabelSession, created.Labels)
+	}
+
+	if err := c.Terminate(context.Background()); nil != err {
+		t.Fatalf("Terminate: %+v", err)
+	}
+	if remaining := backend.Containers(); 0 != len(remaining) {
+		t.Errorf("Containers not removed: %v", remaining)
+	}
+}
+
+func TestStartWaitForReadiness(t *testing.T) {
+	backend := newBackend(t)
+	defer backend.Close()
+	backend.ReadinessDelay = 300 * time.Millisecond
+
+	start := time.Now()
+	c, err := docker.Start(testOptions(backend))
+	if nil != err {
+		t.Fatalf("Start: %+v", err)
+	}
+	defer c.Terminate(context.Background())
+	if elapsed := time.Since(start); elapsed < backend.ReadinessDelay {
+		t.Errorf("Start returned before the container was ready: %v", elapsed)
+	}
+}
+
+func TestStartTimeout(t *testing.T) {
+	backend := newBackend(t)
+	defer backend.Close()
+	backend.ReadinessDelay = time.Minute
+	options := testOptions(backend)
+	options.StartupTimeout = 200 * time.Millisecond
+
+	_, err := docker.Start(options)
+	var timeout *docker.ErrStartupTimeout
+	if !errors.As(err, &timeout) {
+		t.Fatalf("Expected ErrStartupTimeout, got %+v", err)
+	}
+	if remaining := backend.Containers(); 0 != len(remaining) {
+		t.Errorf("Containers not removed: %v", remaining)
+	}
+}
+
+func TestStartStoppedContainer(t *testing.T) {
+	backend := newBackend(t)
+	defer backend.Close()
+	backend.ReadinessDelay = time.Minute
+	go func() {
+		for {
+			if containers := backend.Containers(); 0 != len(containers) {
+				backend.Stop(containers[0].ID, 1)
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	_, err := docker.Start(testOptions(backend))
+	if nil == err {
+		t.Fatal("Start succeeded with a stopped container")
+	}
+	if elapsed := time.Since(start); elapsed >= testOptions(backend).StartupTimeout {
+		t.Errorf("Start waited for the startup timeout: %v", elapsed)
+	}
+	if remaining := backend.Containers(); 0 != len(remaining) {
+		t.Errorf("Containers not removed: %v", remaining)
+	}
+}
+
+func TestStartFailures(t *testing.T) {
+	injected := errors.New("Injected failure")
+	for _, test := range []struct {
+		operation fake.Operation
+		phase     string
+	}{
+		{operation: fake.OperationCreate, phase: docker.PhaseCreate},
+		{operation: fake.OperationStart, phase: docker.PhaseStart},
+	} {
+		t.Run(string(test.operation), func(t *testing.T) {
+			backend := newBackend(t)
+			defer backend.Close()
+			backend.Fail(test.operation, injected)
+
+			_, err := docker.Start(testOptions(backend))
+			var lifecycle *docker.Error
+			if !errors.As(err, &lifecycle) {
+				t.Fatalf("Expected docker.Error, got %+v", err)
+			}
+			if test.phase != lifecycle.Phase {
+				t.Errorf("Phase: expected %s, got %s", test.phase, lifecycle.Phase)
+			}
+			if remaining := backend.Containers(); 0 != len(remaining) {
+				t.Errorf("Containers not removed: %v", remaining)
+			}
+		})
+	}
+}
+
+func TestStartRetryFailures(t *testing.T) {
+	backend := newBackend(t)
+	defer backend.Close()
+	backend.Fail(fake.OperationStart, errors.New("Injected failure"))
+	options := testOptions(backend)
+	options.Retries.Startup = docker.Backoff{Initial: time.Millisecond, MaxAttempts: 2}
+
+	c, err := docker.Start(options)
+	if nil != err {
+		t.Fatalf("Start: %+v", err)
+	}
+	defer c.Terminate(context.Background())
+	if containers := backend.Containers(); 1 != len(containers) {
+		t.Errorf("Expected 1 container, got %v", containers)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
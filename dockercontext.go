@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/pkg/errors"
+)
+
+// defaultContext is the name of the docker context using DOCKER_HOST, or the local socket (See localDockerHost).
+const defaultContext = "default"
+
+// dockerContext is the endpoint of a docker CLI context (See `docker context ls`).
+type dockerContext struct {
+	Name string
+	// Host is the address of the daemon of the context.
+	Host string
+	// TLSDirectory contain the TLS material of the context (ca.pem, cert.pem, key.pem), if any.
+	TLSDirectory string
+	// SkipTLSVerify disable the verification of the daemon certificate.
+	SkipTLSVerify bool
+}
+
+// currentContextName return the context selected for the docker CLI: DOCKER_CONTEXT, or the currentContext of the CLI configuration.
+func currentContextName() string {
+	if name := os.Getenv("DOCKER_CONTEXT"); "" != name {
+		return name
+	}
+	directory, err := dockerConfigDir()
+	if nil != err {
+		return defaultContext
+	}
+	content, err := ioutil.ReadFile(filepath.Join(directory, "config.json"))
+	if nil != err {
+		return defaultContext
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(content, &config); nil != err || "" == config.CurrentContext {
+		return defaultContext
+	}
+	return config.CurrentContext
+}
+
+// loadContext read the endpoint of the named context, from the context store of the docker CLI (contexts/meta/<sha256 of the name>/meta.json).
+func loadContext(name string) (*dockerContext, error) {
+	if defaultContext == name {
+		return &dockerContext{Name: name, Host: localDockerHost()}, nil
+	}
+	directory, err := dockerConfigDir()
+	if nil != err {
+		return nil, err
+	}
+	hash := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(hash[:])
+	content, err := ioutil.ReadFile(filepath.Join(directory, "contexts", "meta", id, "meta.json"))
+	if nil != err {
+		return nil, errors.Wrapf(err, "Reading docker context %s", name)
+	}
+	var meta struct {
+		Endpoints map[string]struct {
+			Host          string
+			SkipTLSVerify bool
+		}
+	}
+	if err := json.Unmarshal(content, &meta); nil != err {
+		return nil, errors.Wrapf(err, "Parsing docker context %s", name)
+	}
+	endpoint, ok := meta.Endpoints["docker"]
+	if !ok || "" == endpoint.Host {
+		return nil, errors.Errorf("Docker context %s has no docker endpoint", name)
+	}
+	loaded := &dockerContext{Name: name, Host: endpoint.Host, SkipTLSVerify: endpoint.SkipTLSVerify}
+	if tlsDirectory := filepath.Join(directory, "contexts", "tls", id, "docker"); nil == statErr(tlsDirectory) {
+		loaded.TLSDirectory = tlsDirectory
+	}
+	return loaded, nil
+}
+
+// tlsConfig return the TLS configuration of the context, or nil if it does not use TLS.
+func (c dockerContext) tlsConfig() (*tls.Config, error) {
+	if "" == c.TLSDirectory {
+		return nil, nil
+	}
+	options := tlsconfig.Options{InsecureSkipVerify: c.SkipTLSVerify}
+	for file, target := range map[string]*string{"ca.pem": &options.CAFile, "cert.pem": &options.CertFile, "key.pem": &options.KeyFile} {
+		if path := filepath.Join(c.TLSDirectory, file); nil == statErr(path) {
+			*target = path
+		}
+	}
+	config, err := tlsconfig.Client(options)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Loading TLS configuration of docker context %s", c.Name)
+	}
+	return config, nil
+}
+
+// contextForHost return the context whose endpoint is the given host, or nil if there is none.
+func contextForHost(host string) *dockerContext {
+	directory, err := dockerConfigDir()
+	if nil != err {
+		return nil
+	}
+	metas, err := filepath.Glob(filepath.Join(directory, "contexts", "meta", "*", "meta.json"))
+	if nil != err {
+		return nil
+	}
+	for _, meta := range metas {
+		content, err := ioutil.ReadFile(meta)
+		if nil != err {
+			continue
+		}
+		var named struct{ Name string }
+		if err := json.Unmarshal(content, &named); nil != err || "" == named.Name {
+			continue
+		}
+		if endpoint, err := loadContext(named.Name); nil == err && host == endpoint.Host {
+			return endpoint
+		}
+	}
+	return nil
+}
+
+// hostTLS return the TLS configuration to use with the daemon at the given host: the configuration of the environment (DOCKER_CERT_PATH, DOCKER_TLS_VERIFY) for TCP hosts, or the TLS material of the docker context of the host. Nil is returned if TLS is not used.
+func hostTLS(host string) (*tls.Config, error) {
+	if certPath := os.Getenv("DOCKER_CERT_PATH"); "" != certPath {
+		if proto, _, _, err := docker.ParseHost(host); nil != err || "tcp" != proto {
+			return nil, err
+		}
+		config, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             filepath.Join(certPath, "ca.pem"),
+			CertFile:           filepath.Join(certPath, "cert.pem"),
+			KeyFile:            filepath.Join(certPath, "key.pem"),
+			InsecureSkipVerify: "" == os.Getenv("DOCKER_TLS_VERIFY"),
+		})
+		if nil != err {
+			return nil, errors.Wrap(err, "Loading docker TLS configuration")
+		}
+		return config, nil
+	}
+	if endpoint := contextForHost(host); nil != endpoint {
+		return endpoint.tlsConfig()
+	}
+	return nil, nil
+}
+
+func statErr(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
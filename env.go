@@ -0,0 +1,147 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ProxyPolicy define whether the proxy settings of the host are propagated into a container (See Options.ProxyEnvironment).
+type ProxyPolicy string
+
+// Supported proxy policies. The empty policy apply the configuration (See Config.ProxyEnvironment).
+const (
+	// ProxyEnabled propagate the proxy settings of the host into the container.
+	ProxyEnabled ProxyPolicy = "enabled"
+	// ProxyDisabled never propagate the proxy settings of the host, even if the configuration enable them.
+	ProxyDisabled ProxyPolicy = "disabled"
+)
+
+// environment return the variable definitions (KEY=value) of the container. Variables defined in Options.EnvironmentVariables take precedence over the ones computed from other options.
+func environment(options Options) []string {
+	variables := gpuEnvironment(options)
+	if ProxyEnabled == options.ProxyEnvironment {
+		for key, value := range proxyEnvironment() {
+			variables[key] = value
+		}
+	}
+	for key, value := range options.EnvironmentVariables {
+		variables[key] = value
+	}
+
+	varDefinitions := make([]string, 0, len(variables))
+	for key, value := range variables {
+		varDefinitions = append(varDefinitions, key+"="+value)
+	}
+	return varDefinitions
+}
+
+// proxyVariables are the proxy settings propagated into the containers (See Options.ProxyEnvironment).
+var proxyVariables = []string{"HTTP_PROXY", "HTTPS_PROXY", "FTP_PROXY", "NO_PROXY"}
+
+// proxyEnvironment return the proxy settings of the host, in upper and lower case as tools read one or the other (eg: curl only read http_proxy).
+func proxyEnvironment() map[string]string {
+	variables := make(map[string]string)
+	for _, name := range proxyVariables {
+		value := os.Getenv(name)
+		if "" == value {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if "" == value {
+			continue
+		}
+		variables[name] = value
+		variables[strings.ToLower(name)] = value
+	}
+	return variables
+}
+
+// withEnvFiles return the options, with the variables of Options.EnvFiles added to EnvironmentVariables. Files are read in order, later files overriding earlier ones, and EnvironmentVariables take precedence over every file.
+func withEnvFiles(options Options) (Options, error) {
+	if 0 == len(options.EnvFiles) {
+		return options, nil
+	}
+	variables := make(map[string]string)
+	for _, path := range options.EnvFiles {
+		parsed, err := parseEnvFile(path)
+		if nil != err {
+			return options, err
+		}
+		for key, value := range parsed {
+			variables[key] = value
+		}
+	}
+	for key, value := range options.EnvironmentVariables {
+		variables[key] = value
+	}
+	options.EnvironmentVariables = variables
+	options.EnvFiles = nil
+	return options, nil
+}
+
+// parseEnvFile parse a dotenv file: one KEY=value definition per line, optionally prefixed by "export". Empty lines and lines starting with # are ignored.
+// Values can be single-quoted (taken literally) or double-quoted (supporting \n, \t, \" and \\ escapes). Unquoted values end at the first " #" comment.
+func parseEnvFile(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Reading environment file %s", path)
+	}
+	variables := make(map[string]string)
+	for number, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if "" == line || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		separator := strings.Index(line, "=")
+		if separator <= 0 {
+			return nil, errors.Errorf("%s:%d: Invalid definition (expected KEY=value): %s", path, number+1, line)
+		}
+		key := strings.TrimSpace(line[:separator])
+		value, err := envValue(strings.TrimSpace(line[separator+1:]))
+		if nil != err {
+			return nil, errors.Wrapf(err, "%s:%d", path, number+1)
+		}
+		variables[key] = value
+	}
+	return variables, nil
+}
+
+// envValue return the value of a dotenv definition, unquoted.
+func envValue(raw string) (string, error) {
+	if strings.HasPrefix(raw, "'") {
+		end := strings.Index(raw[1:], "'")
+		if -1 == end {
+			return "", errors.New("Unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	}
+	if strings.HasPrefix(raw, "\"") {
+		var value strings.Builder
+		for i := 1; i < len(raw); i++ {
+			switch {
+			case '"' == raw[i]:
+				return value.String(), nil
+			case '\\' == raw[i] && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					value.WriteByte('\n')
+				case 't':
+					value.WriteByte('\t')
+				default:
+					value.WriteByte(raw[i])
+				}
+			default:
+				value.WriteByte(raw[i])
+			}
+		}
+		return "", errors.New("Unterminated double-quoted value")
+	}
+	if comment := strings.Index(raw, " #"); -1 != comment {
+		raw = raw[:comment]
+	}
+	return strings.TrimSpace(raw), nil
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// writeTempFile write the content in a temporary file, which must be removed at the end of the test.
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+	file, err := ioutil.TempFile("", "docker-test")
+	if nil != err {
+		t.Fatalf("Creating temporary file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); nil != err {
+		t.Fatalf("Writing temporary file: %v", err)
+	}
+	return file.Name()
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := writeTempFile(t, `# Comment
+PLAIN=value
+export EXPORTED=exported
+  SPACED = spaced value  
+COMMENTED=value # comment
+HASH=value#not-a-comment
+SINGLE='literal \n # value'
+DOUBLE="line\nnext\t\"quoted\" \\ # value"
+EMPTY=
+WINDOWS=crlf`+"\r\n")
+	defer os.Remove(path)
+
+	variables, err := parseEnvFile(path)
+	if nil != err {
+		t.Fatalf("parseEnvFile: %+v", err)
+	}
+	expected := map[string]string{
+		"PLAIN":     "value",
+		"EXPORTED":  "exported",
+		"SPACED":    "spaced value",
+		"COMMENTED": "value",
+		"HASH":      "value#not-a-comment",
+		"SINGLE":    `literal \n # value`,
+		"DOUBLE":    "line\nnext\t\"quoted\" \\ # value",
+		"EMPTY":     "",
+		"WINDOWS":   "crlf",
+	}
+	if !reflect.DeepEqual(expected, variables) {
+		t.Errorf("Expected %q, got %q", expected, variables)
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing separator":    "KEY",
+		"missing key":          "=value",
+		"unterminated single":  "KEY='value",
+		"unterminated double":  `KEY="value`,
+		"escaped double quote": `KEY="value\"`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeTempFile(t, content)
+			defer os.Remove(path)
+			if _, err := parseEnvFile(path); nil == err {
+				t.Errorf("Expected an error for %q", content)
+			}
+		})
+	}
+}
+
+func TestParseEnvFileMissing(t *testing.T) {
+	if _, err := parseEnvFile("/does/not/exist.env"); nil == err {
+		t.Error("Expected an error for a missing file")
+	}
+}
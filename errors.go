@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+// Errors returned by Start and New, allowing callers to decide how to react (eg: skip tests when the daemon is unavailable) with errors.Is.
+var (
+	// ErrDaemonUnavailable is returned when the docker daemon cannot be reached.
+	ErrDaemonUnavailable = errors.New("Docker daemon unavailable")
+	// ErrImagePull is returned when the image is not available locally and cannot be pulled.
+	ErrImagePull = errors.New("Could not pull image")
+	// ErrDigestMismatch is returned when the image digest does not match Options.ImageDigest.
+	ErrDigestMismatch = errors.New("Image digest mismatch")
+	// ErrPortSelection is returned when no host port is available for a PortBinding.
+	ErrPortSelection = errors.New("Could not select host ports")
+)
+
+// ErrStartupTimeout is returned when the container was started, but was not ready before the startup timeout (See Options.StartupTimeout). Use errors.As to access it.
+type ErrStartupTimeout struct {
+	// Err is the error returned by the wait strategy.
+	Err error
+	// State of the container when the startup failed (status, exit code, ...).
+	State string
+	// Logs are the last logs of the container (stdout and stderr).
+	Logs []byte
+}
+
+func (e *ErrStartupTimeout) Error() string {
+	return fmt.Sprintf("%v\nContainer state: {%s}\nContainer logs:\n%s", e.Err, e.State, e.Logs)
+}
+
+// Format print the stack trace of the wait strategy error with %+v, as the errors of github.com/pkg/errors. Other verbs print the message.
+func (e *ErrStartupTimeout) Format(s fmt.State, verb rune) {
+	if 'v' == verb && s.Flag('+') {
+		fmt.Fprintf(s, "%+v\nContainer state: {%s}\nContainer logs:\n%s", e.Err, e.State, e.Logs)
+		return
+	}
+	formatMessage(s, verb, e.Error())
+}
+
+// Unwrap return the error returned by the wait strategy.
+func (e *ErrStartupTimeout) Unwrap() error {
+	return e.Err
+}
+
+// ErrOOMKilled is returned when the container was killed by the kernel for exceeding its memory limit (See Resources.Memory) before being ready. Use errors.As to access it.
+type ErrOOMKilled struct {
+	// MemoryLimit of the container, in bytes. It is 0 if no limit was specified: the host itself ran out of memory.
+	MemoryLimit int64
+	// PeakUsage is the maximum memory used by the container while starting, in bytes. It is 0 if it could not be measured.
+	PeakUsage uint64
+	// Logs are the last logs of the container (stdout and stderr).
+	Logs []byte
+}
+
+func (e *ErrOOMKilled) Error() string {
+	limit := "none"
+	if 0 != e.MemoryLimit {
+		limit = units.BytesSize(float64(e.MemoryLimit))
+	}
+	peak := "unknown"
+	if 0 != e.PeakUsage {
+		peak = units.BytesSize(float64(e.PeakUsage))
+	}
+	return fmt.Sprintf("Container killed for exceeding its memory limit {MemoryLimit: %s, PeakUsage: %s}\nContainer logs:\n%s", limit, peak, e.Logs)
+}
+
+// kindError mark an error as being of a given kind (eg: ErrImagePull), while keeping its cause.
+type kindError struct {
+	kind  error
+	cause error
+}
+
+// withKind return the given error, marked as being of the given kind.
+func withKind(kind error, cause error) error {
+	return &kindError{kind: kind, cause: cause}
+}
+
+func (e *kindError) Error() string {
+	return e.kind.Error() + ": " + e.cause.Error()
+}
+
+// Format print the stack trace of the cause with %+v, as the errors of github.com/pkg/errors. Other verbs print the message.
+func (e *kindError) Format(s fmt.State, verb rune) {
+	if 'v' == verb && s.Flag('+') {
+		fmt.Fprintf(s, "%s: %+v", e.kind, e.cause)
+		return
+	}
+	formatMessage(s, verb, e.Error())
+}
+
+// Is report whether the error is of the given kind.
+func (e *kindError) Is(target error) bool {
+	return e.kind == target
+}
+
+// Unwrap return the cause of the error.
+func (e *kindError) Unwrap() error {
+	return e.cause
+}
+
+// Cause return the cause of the error (See github.com/pkg/errors).
+func (e *kindError) Cause() error {
+	return e.cause
+}
+
+// Phases of the container lifecycle (See Error.Phase).
+const (
+	PhaseClient  = "Client"
+	PhasePull    = "Pull"
+	PhaseBuild   = "Build"
+	PhaseOptions = "Options"
+	PhasePorts   = "Ports"
+	PhaseCreate  = "Create"
+	PhaseStart   = "Start"
+	PhaseWait    = "Wait"
+	PhaseRemove  = "Remove"
+)
+
+// Error give the context of an error happening during the lifecycle of a container. Use errors.As to access it, and errors.Is to check for a specific error (eg: ErrImagePull).
+type Error struct {
+	// Phase of the lifecycle in which the error happened (See PhasePull, PhaseWait, ...).
+	Phase string
+	// Container name (See Options.Name). It include the generated suffix once the container is created.
+	Container string
+	// Image of the container.
+	Image string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s failed {Container: %s, Image: %s}: %v", e.Phase, e.Container, e.Image, e.Err)
+}
+
+// Format print the stack trace of the underlying error with %+v, as the errors of github.com/pkg/errors. Other verbs print the message.
+func (e *Error) Format(s fmt.State, verb rune) {
+	if 'v' == verb && s.Flag('+') {
+		fmt.Fprintf(s, "%s failed {Container: %s, Image: %s}: %+v", e.Phase, e.Container, e.Image, e.Err)
+		return
+	}
+	formatMessage(s, verb, e.Error())
+}
+
+// formatMessage print the message of an error with the given verb (%s, %v or %q).
+func formatMessage(s fmt.State, verb rune, message string) {
+	if 'q' == verb {
+		fmt.Fprintf(s, "%q", message)
+		return
+	}
+	io.WriteString(s, message)
+}
+
+// Unwrap return the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Cause return the underlying error (See github.com/pkg/errors).
+func (e *Error) Cause() error {
+	return e.Err
+}
+
+// lifecycleError return the given error, with the context of the container and the lifecycle phase.
+func lifecycleError(phase string, container string, options Options, err error) error {
+	return &Error{Phase: phase, Container: container, Image: options.Image, Err: err}
+}
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// EventType is the lifecycle transition reported by an Event.
+type EventType string
+
+// Lifecycle events delivered by SubscribeEvents and Container.Events.
+const (
+	EventCreated EventType = "created"
+	EventStarted EventType = "started"
+	EventHealthy EventType = "healthy"
+	EventDied    EventType = "died"
+	EventOOM     EventType = "oom"
+)
+
+// eventTypes map the actions of the daemon events to the lifecycle events. Other actions are ignored.
+var eventTypes = map[string]EventType{
+	"create":                 EventCreated,
+	"start":                  EventStarted,
+	"health_status: healthy": EventHealthy,
+	"die":                    EventDied,
+	"oom":                    EventOOM,
+}
+
+// Event is a lifecycle transition of a container created by this package.
+type Event struct {
+	Type EventType
+	// Identifier of the container.
+	Identifier string
+	// Name of the container, as known by the daemon.
+	Name string
+	// Image of the container.
+	Image string
+	// ExitCode of the container, for EventDied only.
+	ExitCode int
+	Time     time.Time
+}
+
+// SubscribeEvents deliver on the returned channel the lifecycle events of the containers created by the current session (See SessionID), until ctx is done. The channel is then closed.
+// The subscription is resumed, without losing events, if the connection with the daemon is interrupted.
+func SubscribeEvents(ctx context.Context, logger Logger) (<-chan Event, error) {
+	client, err := newClient(nil)
+	if nil != err {
+		return nil, errors.Wrap(err, "Could not create docker client")
+	}
+	args := filters.NewArgs()
+	args.Add("label", LabelSession+"="+SessionID())
+	return subscribe(ctx, client, newLogger(Options{Logger: logger}), args), nil
+}
+
+// Events deliver on the returned channel the lifecycle events of the container, until ctx is done. The channel is then closed.
+// Only the events following the call are delivered: the container being already running, EventCreated and EventStarted are only seen after a restart.
+func (c *Container) Events(ctx context.Context) <-chan Event {
+	args := filters.NewArgs()
+	args.Add("container", c.Identifier)
+	return subscribe(ctx, c.client, c.logger, args)
+}
+
+// subscribe stream the container events matching args, converted to lifecycle events, reconnecting to the daemon when the stream is interrupted.
+func subscribe(ctx context.Context, client *docker.Client, l *eventLogger, args filters.Args) <-chan Event {
+	args.Add("type", "container")
+	delivered := make(chan Event)
+	go func() {
+		defer close(delivered)
+		since := time.Now()
+		var last int64
+		for {
+			messages, errs := client.Events(ctx, types.EventsOptions{
+				Since:   strconv.FormatInt(since.Unix(), 10),
+				Filters: args,
+			})
+		stream:
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case err := <-errs:
+					if nil != ctx.Err() {
+						return
+					}
+					l.Warnf("Event stream interrupted: %+v", err)
+					time.Sleep(stepWaitTime)
+					break stream
+				case message := <-messages:
+					if message.TimeNano <= last {
+						// Already delivered before the stream was resumed
+						continue
+					}
+					last = message.TimeNano
+					since = time.Unix(0, last)
+					event, ok := toEvent(message)
+					if !ok {
+						continue
+					}
+					select {
+					case delivered <- event:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+	return delivered
+}
+
+// toEvent convert a daemon event to a lifecycle event. False is returned for the actions which are not lifecycle transitions (exec, attach, ...).
+func toEvent(message events.Message) (Event, bool) {
+	eventType, ok := eventTypes[strings.TrimSpace(message.Action)]
+	if !ok {
+		return Event{}, false
+	}
+	event := Event{
+		Type:       eventType,
+		Identifier: message.Actor.ID,
+		Name:       message.Actor.Attributes["name"],
+		Image:      message.Actor.Attributes["image"],
+		Time:       time.Unix(0, message.TimeNano),
+	}
+	if EventDied == eventType {
+		event.ExitCode, _ = strconv.Atoi(message.Actor.Attributes["exitCode"])
+	}
+	return event, true
+}
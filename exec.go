@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+// execute run the given command inside the container, and return its exit code and combined output (stdout and stderr).
+func execute(ctx context.Context, client *docker.Client, containerID string, cmd []string) (int, []byte, error) {
+	return executeConfig(ctx, client, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	}, nil)
+}
+
+// executeConfig run the command of the given configuration inside the container, and return its exit code and combined output (stdout and stderr). If the configuration attach the standard input, input is sent to it, followed by the end of the input.
+func executeConfig(ctx context.Context, client *docker.Client, containerID string, config types.ExecConfig, input io.Reader) (int, []byte, error) {
+	cmd := config.Cmd
+	created, err := client.ContainerExecCreate(ctx, containerID, config)
+	if nil != err {
+		return 0, nil, errors.Wrapf(err, "Creating exec %+v", cmd)
+	}
+	attached, err := client.ContainerExecAttach(ctx, created.ID, config)
+	if nil != err {
+		return 0, nil, errors.Wrapf(err, "Attaching exec %+v", cmd)
+	}
+	defer attached.Close()
+
+	if config.AttachStdin {
+		go func() {
+			if nil != input {
+				io.Copy(attached.Conn, input)
+			}
+			attached.CloseWrite()
+		}()
+	}
+	output := &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(output, output, attached.Reader); nil != err {
+		return 0, nil, errors.Wrapf(err, "Reading output of exec %+v", cmd)
+	}
+	inspected, err := client.ContainerExecInspect(ctx, created.ID)
+	if nil != err {
+		return 0, nil, errors.Wrapf(err, "Inspecting exec %+v", cmd)
+	}
+	return inspected.ExitCode, output.Bytes(), nil
+}
+
+// containerLogs return the combined logs (stdout and stderr) of the container.
+func containerLogs(ctx context.Context, client *docker.Client, containerID string) ([]byte, error) {
+	inspected, err := client.ContainerInspect(ctx, containerID)
+	if nil != err {
+		return nil, errors.Wrap(err, "Inspecting container")
+	}
+	reader, err := client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if nil != err {
+		return nil, errors.Wrap(err, "Reading container logs")
+	}
+	defer reader.Close()
+
+	output := &bytes.Buffer{}
+	if nil != inspected.Config && inspected.Config.Tty {
+		// The logs of a TTY are not multiplexed
+		if _, err := io.Copy(output, reader); nil != err {
+			return nil, errors.Wrap(err, "Reading container logs")
+		}
+		return output.Bytes(), nil
+	}
+	if _, err := stdcopy.StdCopy(output, output, reader); nil != err {
+		return nil, errors.Wrap(err, "Demultiplexing container logs")
+	}
+	return output.Bytes(), nil
+}
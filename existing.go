@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FromExisting return a handle on a container started outside of this package (eg: by docker compose), by name or ID, allowing to use the same helpers (Exec, logs, wait strategies, ...).
+// The ports published by the container are available in Ports, with bindings only specifying Protocol and Internal port. Terminate does not remove the container, which is owned by whoever started it.
+func FromExisting(ctx context.Context, nameOrID string) (*Container, error) {
+	client, err := newClient(nil)
+	if nil != err {
+		return nil, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+	inspected, err := client.ContainerInspect(ctx, nameOrID)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting container %s", nameOrID)
+	}
+	reference := inspected.Image
+	if nil != inspected.Config {
+		reference = inspected.Config.Image
+	}
+	image, err := inspectImage(client, inspected.Image)
+	if nil != err {
+		return nil, err
+	}
+	image.Reference = reference
+
+	options := Options{Name: strings.TrimPrefix(inspected.Name, "/"), Image: reference}
+	info := ContainerInfo{
+		Identifier: inspected.ID,
+		Address:    defaultLoopback(),
+		Ports:      make(map[PortBinding]int),
+		Image:      *image,
+	}
+	if err := addPublishedPorts(client, info.Identifier, info.Ports); nil != err {
+		return nil, errors.Wrapf(err, "Listing published ports of %s", nameOrID)
+	}
+	if nil != inspected.NetworkSettings {
+		for _, bindings := range inspected.NetworkSettings.Ports {
+			if 0 == len(bindings) {
+				continue
+			}
+			if address := net.ParseIP(bindings[0].HostIP); nil != address && !address.IsUnspecified() {
+				info.Address = address
+			}
+			break
+		}
+	}
+	if err := reachFromContainer(client, options, &info); nil != err {
+		return nil, err
+	}
+
+	return &Container{
+		ContainerInfo: info,
+		Name:          options.Name,
+		client:        client,
+		logger:        newLogger(options).with(Fields{"container": options.Name}),
+		options:       options,
+		strategy:      forPublishedPorts(),
+		external:      true,
+	}, nil
+}
+
+// WaitUntilReady wait, until the context is done, for the container to be ready according to the given strategy (eg: for an external container, See FromExisting).
+func (c *Container) WaitUntilReady(ctx context.Context, strategy WaitStrategy) error {
+	return strategy.WaitUntilReady(ctx, WaitTarget{
+		ContainerInfo: c.ContainerInfo,
+		client:        c.client,
+		readiness:     withDefaultRetries(c.options).Readiness,
+	})
+}
@@ -0,0 +1,43 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	docker "github.com/docker/docker/client"
+)
+
+// failureLogSize is the maximum size of the container logs attached to a startup failure.
+const failureLogSize = 8 * 1024
+
+// startupFailure attach the state and the last logs of the container to the given startup error, as the root cause of a failed startup is almost always found there.
+// If the container was killed for exceeding its memory limit, an ErrOOMKilled is returned instead, with the given peak memory usage (See followMemoryPeak).
+func startupFailure(client *docker.Client, containerID string, peak uint64, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), maxWaitTime)
+	defer cancel()
+
+	state := "unknown"
+	oomKilled := false
+	var memoryLimit int64
+	if inspected, inspectErr := client.ContainerInspect(ctx, containerID); nil != inspectErr {
+		state = inspectErr.Error()
+	} else if nil != inspected.State {
+		state = fmt.Sprintf("Status: %s, ExitCode: %d, OOMKilled: %t, Error: %q", inspected.State.Status, inspected.State.ExitCode, inspected.State.OOMKilled, inspected.State.Error)
+		oomKilled = inspected.State.OOMKilled
+		if nil != inspected.HostConfig {
+			memoryLimit = inspected.HostConfig.Memory
+		}
+	}
+
+	logs, logsErr := containerLogs(ctx, client, containerID)
+	if nil != logsErr {
+		logs = []byte(logsErr.Error())
+	}
+	if len(logs) > failureLogSize {
+		logs = append([]byte("[...]"), logs[len(logs)-failureLogSize:]...)
+	}
+	if oomKilled {
+		return &ErrOOMKilled{MemoryLimit: memoryLimit, PeakUsage: peak, Logs: logs}
+	}
+	return &ErrStartupTimeout{Err: err, State: state, Logs: logs}
+}
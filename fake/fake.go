@@ -0,0 +1,481 @@
+// Package fake provide an in-memory backend (See docker.ContainerBackend), serving the subset of the docker API used by github.com/normegil/docker.
+// It allow to unit-test code built around this package (test helpers, wrappers, ...) without a docker daemon: containers are only records, and their published ports accept (and immediately close) TCP connections once they are "ready".
+package fake
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Operation is an API operation of the backend, in which failures can be injected (See Backend.Fail).
+type Operation string
+
+// Operations supporting failure injection.
+const (
+	OperationPull   Operation = "pull"
+	OperationCreate Operation = "create"
+	OperationStart  Operation = "start"
+	OperationRemove Operation = "remove"
+)
+
+// apiVersion is the docker API version served by the fake backend.
+const apiVersion = "1.25"
+
+// versionPrefix match the API version prefix of the request paths (eg: /v1.25).
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+// Backend is an in-memory backend, serving the docker API on a loopback port. Use it in Options.Backend (or Group.Backend), and Close it at the end of the test.
+type Backend struct {
+	// ReadinessDelay is the time between the start of a container and the moment its published TCP ports accept connections, to simulate slow services.
+	ReadinessDelay time.Duration
+	// Logs is the output of every container, returned by the logs endpoint (See docker.Container.Logs).
+	Logs string
+
+	mutex      sync.Mutex
+	listener   net.Listener
+	server     *http.Server
+	images     map[string]bool
+	containers map[string]*fakeContainer
+	networks   map[string]string
+	failures   map[Operation][]error
+}
+
+// Container is the state of a container of the backend.
+type Container struct {
+	ID     string
+	Name   string
+	Image  string
+	Env    []string
+	Labels map[string]string
+	// Ports are the published host ports, indexed by container port (eg: 5432/tcp).
+	Ports   map[string]int
+	Running bool
+}
+
+type fakeContainer struct {
+	Container
+	config    *container.Config
+	host      *container.HostConfig
+	started   time.Time
+	exitCode  int
+	listeners []net.Listener
+	timer     *time.Timer
+}
+
+// New start a fake backend.
+func New() (*Backend, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if nil != err {
+		return nil, errors.Wrap(err, "Listening for fake backend API")
+	}
+	b := &Backend{
+		listener:   listener,
+		images:     make(map[string]bool),
+		containers: make(map[string]*fakeContainer),
+		networks:   make(map[string]string),
+		failures:   make(map[Operation][]error),
+	}
+	b.server = &http.Server{Handler: http.HandlerFunc(b.handle)}
+	go b.server.Serve(listener)
+	return b, nil
+}
+
+// Name return "fake".
+func (b *Backend) Name() string {
+	return "fake"
+}
+
+// Host return the address of the API of the backend.
+func (b *Backend) Host() (string, error) {
+	return "tcp://" + b.listener.Addr().String(), nil
+}
+
+// Close stop the API of the backend, and the listeners of its containers.
+func (b *Backend) Close() error {
+	b.mutex.Lock()
+	for _, c := range b.containers {
+		c.stop()
+	}
+	b.mutex.Unlock()
+	return b.server.Close()
+}
+
+// AddImage make the given images ("repository:tag") available without pulling them.
+func (b *Backend) AddImage(references ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, reference := range references {
+		b.images[reference] = true
+	}
+}
+
+// Fail make the next call of the operation fail with the given error. Several failures can be queued for the same operation.
+func (b *Backend) Fail(operation Operation, err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures[operation] = append(b.failures[operation], err)
+}
+
+// Containers return the containers of the backend (not yet removed), sorted by name.
+func (b *Backend) Containers() []Container {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	containers := make([]Container, 0, len(b.containers))
+	for _, c := range b.containers {
+		containers = append(containers, c.Container)
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+	return containers
+}
+
+// Stop stop a running container (by name or ID), as if its main process exited with the given code.
+func (b *Backend) Stop(nameOrID string, exitCode int) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c := b.find(nameOrID)
+	if nil == c {
+		return errors.New("No such container: " + nameOrID)
+	}
+	c.stop()
+	c.exitCode = exitCode
+	return nil
+}
+
+// nextFailure return the next injected failure of the operation, or nil. The mutex must be held.
+func (b *Backend) nextFailure(operation Operation) error {
+	failures := b.failures[operation]
+	if 0 == len(failures) {
+		return nil
+	}
+	b.failures[operation] = failures[1:]
+	return failures[0]
+}
+
+// find return the container with the given name or ID, or nil. The mutex must be held.
+func (b *Backend) find(nameOrID string) *fakeContainer {
+	if c, ok := b.containers[nameOrID]; ok {
+		return c
+	}
+	for _, c := range b.containers {
+		if c.Name == strings.TrimPrefix(nameOrID, "/") {
+			return c
+		}
+	}
+	return nil
+}
+
+func (b *Backend) handle(w http.ResponseWriter, r *http.Request) {
+	path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch {
+	case "/_ping" == path:
+		w.Write([]byte("OK"))
+	case "/version" == path:
+		writeJSON(w, types.Version{APIVersion: apiVersion, MinAPIVersion: apiVersion, Os: "linux", Arch: "amd64"})
+	case "/images/json" == path && http.MethodGet == r.Method:
+		b.listImages(w)
+	case "/images/create" == path && http.MethodPost == r.Method:
+		b.pullImage(w, r)
+	case strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		b.inspectImage(w, strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json"))
+	case "/containers/create" == path && http.MethodPost == r.Method:
+		b.createContainer(w, r)
+	case "/networks/create" == path && http.MethodPost == r.Method:
+		b.createNetwork(w, r)
+	case strings.HasPrefix(path, "/networks/") && http.MethodDelete == r.Method:
+		delete(b.networks, strings.TrimPrefix(path, "/networks/"))
+		w.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "/containers/"):
+		parts := strings.SplitN(strings.TrimPrefix(path, "/containers/"), "/", 2)
+		c := b.find(parts[0])
+		if nil == c {
+			writeError(w, http.StatusNotFound, errors.New("No such container: "+parts[0]))
+			return
+		}
+		action := ""
+		if 2 == len(parts) {
+			action = parts[1]
+		}
+		b.handleContainer(w, r, c, action)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("Unsupported by fake backend: %s %s", r.Method, path))
+	}
+}
+
+func (b *Backend) handleContainer(w http.ResponseWriter, r *http.Request, c *fakeContainer, action string) {
+	switch {
+	case "" == action && http.MethodDelete == r.Method:
+		if err := b.nextFailure(OperationRemove); nil != err {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		c.stop()
+		delete(b.containers, c.ID)
+		w.WriteHeader(http.StatusNoContent)
+	case "start" == action:
+		if err := b.nextFailure(OperationStart); nil != err {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := b.start(c); nil != err {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "stop" == action || "kill" == action:
+		c.stop()
+		w.WriteHeader(http.StatusNoContent)
+	case "json" == action:
+		writeJSON(w, c.inspect())
+	case "logs" == action:
+		writeLogs(w, b.Logs, c.config.Tty)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("Unsupported by fake backend: %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (b *Backend) listImages(w http.ResponseWriter) {
+	images := make([]types.ImageSummary, 0, len(b.images))
+	for reference := range b.images {
+		images = append(images, types.ImageSummary{ID: imageID(reference), RepoTags: []string{reference}})
+	}
+	writeJSON(w, images)
+}
+
+func (b *Backend) pullImage(w http.ResponseWriter, r *http.Request) {
+	reference := r.URL.Query().Get("fromImage")
+	if tag := r.URL.Query().Get("tag"); "" != tag && !strings.Contains(reference, "@") {
+		reference += ":" + tag
+	}
+	if err := b.nextFailure(OperationPull); nil != err {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	b.images[reference] = true
+	writeJSON(w, map[string]string{"status": "Downloaded newer image for " + reference})
+}
+
+func (b *Backend) inspectImage(w http.ResponseWriter, reference string) {
+	if !b.images[reference] {
+		writeError(w, http.StatusNotFound, errors.New("No such image: "+reference))
+		return
+	}
+	writeJSON(w, types.ImageInspect{ID: imageID(reference), RepoTags: []string{reference}, Config: &container.Config{}})
+}
+
+func (b *Backend) createContainer(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		*container.Config
+		HostConfig       *container.HostConfig
+		NetworkingConfig *network.NetworkingConfig
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := b.nextFailure(OperationCreate); nil != err {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if nil != b.find(name) {
+		writeError(w, http.StatusConflict, fmt.Errorf("Conflict. The container name \"/%s\" is already in use", name))
+		return
+	}
+	if nil == request.Config {
+		request.Config = &container.Config{}
+	}
+	if nil == request.HostConfig {
+		request.HostConfig = &container.HostConfig{}
+	}
+	id := strings.Replace(uuid.New().String(), "-", "", -1)
+	b.containers[id] = &fakeContainer{
+		Container: Container{
+			ID:     id,
+			Name:   name,
+			Image:  request.Image,
+			Env:    request.Env,
+			Labels: request.Labels,
+			Ports:  make(map[string]int),
+		},
+		config: request.Config,
+		host:   request.HostConfig,
+	}
+	writeJSON(w, container.ContainerCreateCreatedBody{ID: id})
+}
+
+func (b *Backend) createNetwork(w http.ResponseWriter, r *http.Request) {
+	var request types.NetworkCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	id := strings.Replace(uuid.New().String(), "-", "", -1)
+	b.networks[id] = request.Name
+	writeJSON(w, types.NetworkCreateResponse{ID: id})
+}
+
+// start publish the ports of the container, which accept connections after the readiness delay.
+func (b *Backend) start(c *fakeContainer) error {
+	if c.Running {
+		return nil
+	}
+	bindings := make(map[string]string)
+	for port, portBindings := range c.host.PortBindings {
+		if 0 == len(portBindings) {
+			continue
+		}
+		bindings[string(port)] = net.JoinHostPort(portBindings[0].HostIP, portBindings[0].HostPort)
+	}
+	if c.host.PublishAllPorts {
+		for port := range c.config.ExposedPorts {
+			if _, ok := bindings[string(port)]; !ok {
+				bindings[string(port)] = ":0"
+			}
+		}
+	}
+
+	addresses := make([]string, 0, len(bindings))
+	for port, address := range bindings {
+		if "tcp" != nat.Port(port).Proto() {
+			continue
+		}
+		host, hostPort, _ := net.SplitHostPort(address)
+		if "0" == hostPort || "" == hostPort {
+			// Reserve a port now, as published ports are known as soon as the container is started
+			listener, err := net.Listen("tcp", address)
+			if nil != err {
+				return errors.Wrapf(err, "Publishing port %s", port)
+			}
+			hostPort = strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+			listener.Close()
+		}
+		c.Ports[port], _ = strconv.Atoi(hostPort)
+		addresses = append(addresses, net.JoinHostPort(host, hostPort))
+	}
+	c.Running = true
+	c.started = time.Now()
+	c.timer = time.AfterFunc(b.ReadinessDelay, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		if !c.Running {
+			return
+		}
+		for _, address := range addresses {
+			listener, err := net.Listen("tcp", address)
+			if nil != err {
+				// The port stay closed, like a service failing to start
+				continue
+			}
+			c.listeners = append(c.listeners, listener)
+			go accept(listener)
+		}
+	})
+	return nil
+}
+
+// stop close the published ports of the container.
+func (c *fakeContainer) stop() {
+	if nil != c.timer {
+		c.timer.Stop()
+	}
+	for _, listener := range c.listeners {
+		listener.Close()
+	}
+	c.listeners = nil
+	c.Running = false
+}
+
+func (c *fakeContainer) inspect() types.ContainerJSON {
+	status := "created"
+	if c.Running {
+		status = "running"
+	} else if !c.started.IsZero() {
+		status = "exited"
+	}
+	ports := make(nat.PortMap, len(c.Ports))
+	for port, hostPort := range c.Ports {
+		ports[nat.Port(port)] = []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: strconv.Itoa(hostPort)}}
+	}
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    c.ID,
+			Name:  "/" + c.Name,
+			Image: imageID(c.Image),
+			State: &types.ContainerState{
+				Status:    status,
+				Running:   c.Running,
+				ExitCode:  c.exitCode,
+				StartedAt: c.started.UTC().Format(time.RFC3339Nano),
+			},
+			HostConfig: c.host,
+		},
+		Config: c.config,
+		NetworkSettings: &types.NetworkSettings{
+			NetworkSettingsBase: types.NetworkSettingsBase{Ports: ports},
+		},
+	}
+}
+
+// accept accept and immediately close the connections, until the listener is closed.
+func accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if nil != err {
+			return
+		}
+		conn.Close()
+	}
+}
+
+func imageID(reference string) string {
+	return "sha256:" + strings.Replace(uuid.NewSHA1(uuid.NameSpaceURL, []byte(reference)).String(), "-", "", -1)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(types.ErrorResponse{Message: err.Error()})
+}
+
+// writeLogs write the logs in the multiplexed format of the API (See stdcopy), as stdout. The logs of containers with a TTY are written as is.
+func writeLogs(w http.ResponseWriter, logs string, tty bool) {
+	w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+	if "" == logs {
+		return
+	}
+	if tty {
+		w.Write([]byte(logs))
+		return
+	}
+	header := make([]byte, 8)
+	header[0] = 1
+	binary.BigEndian.PutUint32(header[4:], uint32(len(logs)))
+	w.Write(header)
+	w.Write([]byte(logs))
+}
@@ -0,0 +1,74 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// File is a file to copy inside the container before it is started (eg: configuration, initialization scripts).
+type File struct {
+	// ContainerPath is the absolute path of the file inside the container. Missing parent directories are created.
+	ContainerPath string
+	// Content of the file.
+	Content []byte
+	// Mode of the file. Default to 0644.
+	Mode int64
+	// UID and GID owning the file. Default to root.
+	UID int
+	GID int
+}
+
+// CopyFiles copy the files inside the container (eg: generated configuration files, from Hooks.PostCreate or Options.Configure). Missing parent directories are created.
+func (c *Container) CopyFiles(ctx context.Context, files ...File) error {
+	c.logger.Printf("Copying files in container: " + c.Name)
+	archive, err := toTar(files)
+	if nil != err {
+		return err
+	}
+	if err := c.client.CopyToContainer(ctx, c.Identifier, "/", archive, types.CopyToContainerOptions{}); nil != err {
+		return errors.Wrapf(err, "Copying files in %s", c.Name)
+	}
+	return nil
+}
+
+func copyFiles(client *docker.Client, containerID string, files []File) error {
+	archive, err := toTar(files)
+	if nil != err {
+		return err
+	}
+	return client.CopyToContainer(context.Background(), containerID, "/", archive, types.CopyToContainerOptions{})
+}
+
+func toTar(files []File) (*bytes.Buffer, error) {
+	buffer := &bytes.Buffer{}
+	writer := tar.NewWriter(buffer)
+	for _, file := range files {
+		mode := file.Mode
+		if 0 == mode {
+			mode = 0644
+		}
+		header := &tar.Header{
+			Name: strings.TrimPrefix(file.ContainerPath, "/"),
+			Mode: mode,
+			Size: int64(len(file.Content)),
+			Uid:  file.UID,
+			Gid:  file.GID,
+		}
+		if err := writer.WriteHeader(header); nil != err {
+			return nil, errors.Wrapf(err, "Writing header of %s", file.ContainerPath)
+		}
+		if _, err := writer.Write(file.Content); nil != err {
+			return nil, errors.Wrapf(err, "Writing content of %s", file.ContainerPath)
+		}
+	}
+	if err := writer.Close(); nil != err {
+		return nil, errors.Wrap(err, "Closing archive")
+	}
+	return buffer, nil
+}
@@ -11,7 +11,8 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/normegil/connectionutils v0.0.0-20181220171258-4a33da0f3393
 	github.com/normegil/interval v0.0.0-20181220165130-6c2976dd2323
-	github.com/pkg/errors v0.8.0
+	github.com/pkg/errors v0.9.1
 	golang.org/x/net v0.0.0-20171024115130-4b14673ba32b
 	golang.org/x/sys v0.0.0-20171026072640-3e3646d2c706
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,205 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	docker "github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Group declare several containers working together (eg: a service with its database and message broker). The containers are started in dependency order, independent ones in parallel, on a network dedicated to the group.
+type Group struct {
+	// Members of the group, indexed by name. The name is the network alias of the member, allowing the other members to reach it (eg: "db" for postgres://db:5432).
+	Members map[string]GroupMember
+	// Logger used for the group operations. Members use their own Options.Logger.
+	Logger Logger
+	// Progress, if specified, track the startup of the members which don't specify their own Options.Progress.
+	Progress *Progress
+	// Backend on which the network and the members are created. Default to DetectBackend().
+	Backend ContainerBackend
+	// Client, if specified, is used to create the network and the members (See Options.Client).
+	Client *docker.Client
+}
+
+// GroupMember is a container of a Group.
+type GroupMember struct {
+	// Options of the container. Name default to the member name, Backend and Client to the ones of the group, and the container is attached to the group network, which must be reachable from its backend. Options.WaitStrategy define when the member is ready.
+	Options Options
+	// DependsOn are the names of the members which must be ready before starting this one.
+	DependsOn []string
+}
+
+// Environment is a started Group.
+type Environment struct {
+	// Network is the name of the network of the group.
+	Network string
+	// Containers of the group, indexed by member name.
+	Containers map[string]*Container
+
+	client    *docker.Client
+	logger    *eventLogger
+	networkID string
+}
+
+// Start create the network of the group, and start its members in dependency order. If a member cannot be started, the members already started are terminated and the network removed.
+func (g Group) Start(ctx context.Context) (*Environment, error) {
+	if err := g.checkDependencies(); nil != err {
+		return nil, err
+	}
+	l := newLogger(Options{Logger: g.Logger})
+	client, err := clientOf(Options{Backend: g.Backend, Client: g.Client}, l)
+	if nil != err {
+		return nil, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+
+	networkName := "group-" + uuid.New().String()
+	l.Printf("Creating network: " + networkName)
+	networkID, err := createNetwork(ctx, client, networkName)
+	if nil != err {
+		return nil, err
+	}
+	environment := &Environment{
+		Network:    networkName,
+		Containers: make(map[string]*Container, len(g.Members)),
+		client:     client,
+		logger:     l,
+		networkID:  networkID,
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	ready := make(map[string]chan struct{}, len(g.Members))
+	for name := range g.Members {
+		ready[name] = make(chan struct{})
+	}
+	failed := make(chan struct{})
+	var failOnce sync.Once
+	failures := make([]string, 0)
+	fail := func(name string, err error) {
+		mutex.Lock()
+		failures = append(failures, name+": "+err.Error())
+		mutex.Unlock()
+		failOnce.Do(func() { close(failed) })
+	}
+
+	for name, member := range g.Members {
+		wg.Add(1)
+		go func(name string, member GroupMember) {
+			defer wg.Done()
+			for _, dependency := range member.DependsOn {
+				select {
+				case <-ready[dependency]:
+				case <-failed:
+					return
+				case <-ctx.Done():
+					fail(name, ctx.Err())
+					return
+				}
+			}
+			l.Printf("Starting group member: " + name)
+			c, err := Start(g.memberOptions(name, member, networkName))
+			if nil != err {
+				fail(name, err)
+				return
+			}
+			mutex.Lock()
+			environment.Containers[name] = c
+			mutex.Unlock()
+			close(ready[name])
+		}(name, member)
+	}
+	wg.Wait()
+
+	if 0 != len(failures) {
+		sort.Strings(failures)
+		if err := environment.Terminate(context.Background()); nil != err {
+			l.Errorf("Could not terminate group: %+v", err)
+		}
+		return nil, errors.New("Starting group: " + strings.Join(failures, "; "))
+	}
+	return environment, nil
+}
+
+// memberOptions return the options of the member container, attached to the group network. The backend and client of the group are used by the members which do not specify their own.
+func (g Group) memberOptions(name string, member GroupMember, network string) Options {
+	options := member.Options
+	if "" == options.Name {
+		options.Name = name
+	}
+	if nil == options.Progress {
+		options.Progress = g.Progress
+	}
+	if nil == options.Backend {
+		options.Backend = g.Backend
+	}
+	if nil == options.Client {
+		options.Client = g.Client
+	}
+	options.Network = network
+	options.NetworkAliases = append([]string{name}, options.NetworkAliases...)
+	return options
+}
+
+// checkDependencies check that every dependency is a member of the group, and that there is no dependency cycle.
+func (g Group) checkDependencies() error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make(map[string]int, len(g.Members))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch states[name] {
+		case visiting:
+			return errors.New("Dependency cycle: " + strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		states[name] = visiting
+		for _, dependency := range g.Members[name].DependsOn {
+			if _, ok := g.Members[dependency]; !ok {
+				return errors.Errorf("Unknown dependency of %s: %s", name, dependency)
+			}
+			if err := visit(dependency, append(path, name)); nil != err {
+				return err
+			}
+		}
+		states[name] = visited
+		return nil
+	}
+	for name := range g.Members {
+		if err := visit(name, nil); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get return the container of the given member, or nil if there is no such member.
+func (e *Environment) Get(name string) *Container {
+	return e.Containers[name]
+}
+
+// Terminate remove the containers of the group, then its network.
+func (e *Environment) Terminate(ctx context.Context) error {
+	failures := make([]string, 0)
+	for name, c := range e.Containers {
+		if err := c.Terminate(ctx); nil != err {
+			failures = append(failures, name+": "+err.Error())
+		}
+	}
+	e.logger.Printf("Removing network: " + e.Network)
+	if err := e.client.NetworkRemove(ctx, e.networkID); nil != err && !docker.IsErrNetworkNotFound(err) {
+		failures = append(failures, "network "+e.Network+": "+err.Error())
+	}
+	if 0 != len(failures) {
+		sort.Strings(failures)
+		return errors.New("Terminating group: " + strings.Join(failures, "; "))
+	}
+	return nil
+}
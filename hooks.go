@@ -0,0 +1,32 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Hooks are callbacks called at the phases of the container lifecycle, for setups the options don't cover (eg: registering the container in an external inventory, flushing data before termination).
+// A hook returning an error abort the startup (the container is removed), except PreTerminate whose error is only logged.
+type Hooks struct {
+	// PreCreate is called before pulling the image and creating the container, and can modify the options.
+	PreCreate func(ctx context.Context, options *Options) error
+	// PostCreate is called once the container is created, before starting it (eg: to copy generated files in the container). The container is not running: only its identifier and ports are available.
+	PostCreate func(ctx context.Context, c *Container) error
+	// PostStart is called once the container is ready.
+	PostStart func(ctx context.Context, c *Container) error
+	// PreTerminate is called before terminating the container (See Container.Terminate).
+	PreTerminate func(ctx context.Context, c *Container) error
+}
+
+// runHook call the hook, if specified, wrapping its error with the hook name.
+func runHook(ctx context.Context, name string, hook func(ctx context.Context, c *Container) error, c *Container) error {
+	if nil == hook {
+		return nil
+	}
+	c.logger.Debugf("Running %s hook of %s", name, c.Name)
+	if err := hook(ctx, c); nil != err {
+		return errors.Wrapf(err, "%s hook", name)
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package docker
+
+// localDockerHost return the address of the local docker daemon (See localDockerSocket).
+func localDockerHost() string {
+	return "unix://" + localDockerSocket()
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package docker
+
+import docker "github.com/docker/docker/client"
+
+// localDockerHost return the address of the local docker daemon: the named pipe of Docker for Windows (npipe:////./pipe/docker_engine).
+func localDockerHost() string {
+	return docker.DefaultDockerHost
+}
@@ -0,0 +1,215 @@
+package docker
+
+import (
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
+)
+
+// Resources limit the resources a container can use, to avoid heavyweight containers starving the host. Zero values mean no limit.
+type Resources struct {
+	// Memory limit, in bytes.
+	Memory int64
+	// MemorySwap is the total memory (memory + swap) limit, in bytes. Set to -1 to allow unlimited swap.
+	MemorySwap int64
+	// NanoCPUs is the CPU quota, in units of 10^-9 CPUs (eg: 1500000000 for 1.5 CPUs).
+	NanoCPUs int64
+	// CPUPeriod and CPUQuota limit the CPU usage, using the CFS scheduler (CPUQuota microseconds per CPUPeriod microseconds). Cannot be used with NanoCPUs.
+	CPUPeriod int64
+	CPUQuota  int64
+	// PidsLimit is the maximum number of processes in the container.
+	PidsLimit int64
+	// ShmSize is the size of /dev/shm, in bytes.
+	ShmSize int64
+}
+
+// Ulimit is a resource limit of the container processes (See ulimit command).
+type Ulimit struct {
+	// Name of the limit (eg: nofile, nproc, memlock).
+	Name string
+	Soft int64
+	Hard int64
+}
+
+func toDockerUlimits(ulimits []Ulimit) []*units.Ulimit {
+	converted := make([]*units.Ulimit, 0, len(ulimits))
+	for _, ulimit := range ulimits {
+		converted = append(converted, &units.Ulimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+	return converted
+}
+
+// Device is a host device mapped into the container.
+type Device struct {
+	// PathOnHost is the path of the device on the host (eg: /dev/fuse).
+	PathOnHost string
+	// PathInContainer is the path of the device inside the container. Default to PathOnHost.
+	PathInContainer string
+	// CgroupPermissions define the allowed access to the device (r: read, w: write, m: mknod). Default to rwm.
+	CgroupPermissions string
+}
+
+func toDockerDevices(devices []Device) []container.DeviceMapping {
+	converted := make([]container.DeviceMapping, 0, len(devices))
+	for _, device := range devices {
+		mapping := container.DeviceMapping{
+			PathOnHost:        device.PathOnHost,
+			PathInContainer:   device.PathInContainer,
+			CgroupPermissions: device.CgroupPermissions,
+		}
+		if "" == mapping.PathInContainer {
+			mapping.PathInContainer = mapping.PathOnHost
+		}
+		if "" == mapping.CgroupPermissions {
+			mapping.CgroupPermissions = "rwm"
+		}
+		converted = append(converted, mapping)
+	}
+	return converted
+}
+
+// runtime return the runtime to use for the container.
+func runtime(options Options) string {
+	if "" == options.Runtime && "" != options.GPUs {
+		return "nvidia"
+	}
+	return options.Runtime
+}
+
+// gpuEnvironment return the environment variables used by the nvidia runtime to select the GPUs to expose.
+func gpuEnvironment(options Options) map[string]string {
+	if "" == options.GPUs {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"NVIDIA_VISIBLE_DEVICES":     options.GPUs,
+		"NVIDIA_DRIVER_CAPABILITIES": "all",
+	}
+}
+
+// RestartPolicy define how the daemon restart a container when it exits.
+type RestartPolicy struct {
+	// Name of the policy: no, always, unless-stopped or on-failure.
+	Name string
+	// MaximumRetryCount is the number of restart attempts, for the on-failure policy.
+	MaximumRetryCount int
+}
+
+// LogConfig define how the daemon store the logs of a container.
+type LogConfig struct {
+	// Driver storing the logs (eg: json-file, local, journald, syslog). Default to the daemon default driver (usually json-file).
+	Driver string
+	// Options of the driver (eg: max-size=10m and max-file=3 for json-file, to cap the disk used by chatty images).
+	Options map[string]string
+}
+
+// Bind is a host path (file or directory) mounted inside the container.
+type Bind struct {
+	// HostPath is the absolute path on the host.
+	HostPath string
+	// ContainerPath is the absolute path inside the container.
+	ContainerPath string
+	// ReadOnly mount the path in read-only mode.
+	ReadOnly bool
+}
+
+// String return the docker representation of the bind (host:container[:ro]).
+func (b Bind) String() string {
+	bind := b.HostPath + ":" + b.ContainerPath
+	if b.ReadOnly {
+		bind += ":ro"
+	}
+	return bind
+}
+
+func toDockerBinds(binds []Bind) []string {
+	converted := make([]string, 0, len(binds))
+	for _, bind := range binds {
+		converted = append(converted, bind.String())
+	}
+	return converted
+}
+
+func containerConfig(options Options, image ImageInfo) *container.Config {
+	config := &container.Config{
+		Image:        options.Image,
+		ExposedPorts: toExposedPorts(options.Ports),
+		Env:          environment(options),
+		StopSignal:   options.StopSignal,
+		Labels:       labels(options),
+		Cmd:          strslice.StrSlice(options.Cmd),
+		Entrypoint:   strslice.StrSlice(options.Entrypoint),
+		Tty:          options.Tty,
+		OpenStdin:    options.OpenStdin,
+	}
+	if 0 < options.StopTimeout {
+		seconds := int(options.StopTimeout.Seconds())
+		config.StopTimeout = &seconds
+	}
+	if nil != options.Configure {
+		// The configuration script wait for the secrets (See configure)
+		config.Entrypoint = strslice.StrSlice(awaitConfiguration)
+		config.Cmd = nil
+	} else if 0 != len(options.Secrets) {
+		config.Entrypoint = strslice.StrSlice(append(append([]string{}, awaitSecrets...), configuredCommand(options, image)...))
+		config.Cmd = nil
+	}
+	return config
+}
+
+func toDockerResources(resources Resources) container.Resources {
+	return container.Resources{
+		Memory:     resources.Memory,
+		MemorySwap: resources.MemorySwap,
+		NanoCPUs:   resources.NanoCPUs,
+		CPUPeriod:  resources.CPUPeriod,
+		CPUQuota:   resources.CPUQuota,
+		PidsLimit:  resources.PidsLimit,
+	}
+}
+
+func hostConfig(options Options, portBindings nat.PortMap) *container.HostConfig {
+	resources := toDockerResources(options.Resources)
+	resources.Ulimits = toDockerUlimits(options.Ulimits)
+	resources.Devices = toDockerDevices(options.Devices)
+	resources.CgroupParent = options.CgroupParent
+	config := &container.HostConfig{
+		PortBindings:    portBindings,
+		Binds:           toDockerBinds(options.Binds),
+		PublishAllPorts: options.PublishAllPorts,
+		ShmSize:         options.Resources.ShmSize,
+		Privileged:      options.Privileged,
+		CapAdd:          options.CapAdd,
+		CapDrop:         options.CapDrop,
+		SecurityOpt:     options.SecurityOpt,
+		GroupAdd:        options.GroupAdd,
+		PidMode:         container.PidMode(options.PidMode),
+		IpcMode:         container.IpcMode(options.IpcMode),
+		UsernsMode:      container.UsernsMode(options.UsernsMode),
+		Sysctls:         options.Sysctls,
+		Runtime:         runtime(options),
+		Isolation:       container.Isolation(options.Isolation),
+		Tmpfs:           tmpfs(options),
+		ReadonlyRootfs:  options.ReadonlyRootfs,
+		AutoRemove:      options.AutoRemove,
+		RestartPolicy: container.RestartPolicy{
+			Name:              options.RestartPolicy.Name,
+			MaximumRetryCount: options.RestartPolicy.MaximumRetryCount,
+		},
+		LogConfig: container.LogConfig{
+			Type:   options.LogConfig.Driver,
+			Config: options.LogConfig.Options,
+		},
+		Resources:   resources,
+		NetworkMode: container.NetworkMode(options.Network),
+	}
+	if options.Init {
+		config.Init = &options.Init
+	}
+	return config
+}
@@ -0,0 +1,138 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// PullPolicy define when the image of a container is pulled (See Options.PullPolicy). Values are the ones of the `docker run --pull` flag.
+type PullPolicy string
+
+// Supported pull policies.
+const (
+	// PullIfNotPresent only pull the image if no local image match the reference.
+	PullIfNotPresent PullPolicy = "missing"
+	// PullAlways pull the image before every container creation, to catch updates of the image tag.
+	PullAlways PullPolicy = "always"
+	// PullNever never pull the image, and fail if it is not available locally (eg: air-gapped runners).
+	PullNever PullPolicy = "never"
+)
+
+// PullEvent is a progress event received while pulling an image (See Options.PullProgress).
+type PullEvent struct {
+	// Image being pulled.
+	Image string
+	// Layer is the identifier of the layer the event is about. Empty for events about the whole image.
+	Layer string
+	// Status of the layer (eg: Downloading, Extracting, Pull complete).
+	Status string
+	// Current and Total are the number of bytes already processed, and to process, for the current status of the layer. Zero if unknown.
+	Current int64
+	Total   int64
+}
+
+// ImageInfo describe the image used to create a container, allowing to know exactly which build of an image was used.
+type ImageInfo struct {
+	// Reference is the image name, as specified in the options.
+	Reference string
+	// ID is the resolved image ID (sha256:...).
+	ID string
+	// Digest is the repository digest of the image (sha256:...), if the image was pulled from a registry.
+	Digest string
+	// RepoDigests are all the repository digests of the image (repository@sha256:...).
+	RepoDigests []string
+	// Labels defined on the image.
+	Labels map[string]string
+	// Size of the image, in bytes.
+	Size int64
+	// OS is the operating system of the image (linux, windows).
+	OS string
+	// Entrypoint and Cmd are the default command of the image.
+	Entrypoint []string
+	Cmd        []string
+}
+
+func inspectImage(client *docker.Client, reference string) (*ImageInfo, error) {
+	image, _, err := client.ImageInspectWithRaw(context.Background(), reference)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting image %s", reference)
+	}
+	info := &ImageInfo{
+		Reference:   reference,
+		ID:          image.ID,
+		Digest:      selectDigest(reference, image.RepoDigests),
+		RepoDigests: image.RepoDigests,
+		Size:        image.Size,
+		OS:          image.Os,
+	}
+	if nil != image.Config {
+		info.Labels = image.Config.Labels
+		info.Entrypoint = image.Config.Entrypoint
+		info.Cmd = image.Config.Cmd
+	}
+	return info, nil
+}
+
+// selectDigest return the digest from the repo digests (repository@sha256:...) matching the repository of the reference, or the first one if none match.
+func selectDigest(reference string, repoDigests []string) string {
+	repository := repositoryOf(reference)
+	digest := ""
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if 2 != len(parts) {
+			continue
+		}
+		if parts[0] == repository {
+			return parts[1]
+		}
+		if "" == digest {
+			digest = parts[1]
+		}
+	}
+	return digest
+}
+
+// verifyDigest check that the image has the given pinned digest (sha256:...), in any of its repositories.
+func (i ImageInfo) verifyDigest(pinned string) error {
+	for _, repoDigest := range i.RepoDigests {
+		if strings.HasSuffix(repoDigest, "@"+pinned) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Image %s digest %s does not match pinned digest %s", i.Reference, i.Digest, pinned)
+}
+
+// localImageMatch check if the local image match the reference: by digest for references containing one (repository@sha256:...), by tag otherwise.
+func localImageMatch(reference string, image types.ImageSummary) bool {
+	if index := strings.Index(reference, "@"); -1 != index {
+		expected := repositoryOf(reference) + reference[index:]
+		for _, repoDigest := range image.RepoDigests {
+			if repoDigest == expected {
+				return true
+			}
+		}
+		return false
+	}
+	for _, tag := range image.RepoTags {
+		if tag == reference {
+			return true
+		}
+	}
+	return false
+}
+
+// repositoryOf return the repository part of an image reference, without tag nor digest.
+func repositoryOf(reference string) string {
+	if index := strings.Index(reference, "@"); -1 != index {
+		reference = reference[:index]
+	}
+	if index := strings.LastIndex(reference, ":"); -1 != index && !strings.Contains(reference[index:], "/") {
+		reference = reference[:index]
+	}
+	return reference
+}
@@ -0,0 +1,47 @@
+package docker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Labels added by this package on every container it create, allowing external tools to find the containers leaked by crashed test runs.
+const (
+	// LabelSession identify the test process which created the container (See SessionID). Reusable containers (See Options.Reuse) don't have it, as they outlive the session: they would otherwise be removed by the reaper of the session.
+	LabelSession = "org.normegil.docker.session"
+	// LabelCreator identify this package as the creator of the container.
+	LabelCreator = "org.normegil.docker.creator"
+	// LabelCreated is the creation time of the container (RFC 3339).
+	LabelCreated = "org.normegil.docker.created"
+)
+
+const creator = "github.com/normegil/docker"
+
+var sessionID string
+var sessionOnce sync.Once
+
+// SessionID return the identifier of the current process session, added to the labels of every container created by this process.
+func SessionID() string {
+	sessionOnce.Do(func() {
+		sessionID = uuid.New().String()
+	})
+	return sessionID
+}
+
+// labels return the labels of the container: the labels specified in the options, and the labels managed by this package.
+func labels(options Options) map[string]string {
+	labels := make(map[string]string, len(options.Labels)+3)
+	for key, value := range options.Labels {
+		labels[key] = value
+	}
+	labels[LabelCreator] = creator
+	labels[LabelCreated] = time.Now().UTC().Format(time.RFC3339)
+	if options.Reuse {
+		labels[LabelReuse] = reuseKey(options)
+	} else {
+		labels[LabelSession] = SessionID()
+	}
+	return labels
+}
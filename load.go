@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// LoadImage load the images contained in a tar archive (as created by `docker save`) into the daemon, and return their references.
+// It allow CI environments without registry access to use images saved beforehand (See also Options.ImageTarPath).
+func LoadImage(ctx context.Context, archive io.Reader) ([]string, error) {
+	client, err := newClient(nil)
+	if nil != err {
+		return nil, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+	return loadImage(ctx, client, archive)
+}
+
+func loadImage(ctx context.Context, client *docker.Client, archive io.Reader) ([]string, error) {
+	response, err := client.ImageLoad(ctx, archive, true)
+	if nil != err {
+		return nil, errors.Wrap(err, "Loading images")
+	}
+	defer response.Body.Close()
+
+	loaded := make([]string, 0)
+	stream := json.NewDecoder(response.Body)
+	for {
+		var event struct {
+			Stream string `json:"stream"`
+			Error  string `json:"error"`
+		}
+		if err := stream.Decode(&event); nil != err {
+			if io.EOF == err {
+				break
+			}
+			return nil, errors.Wrap(err, "Loading images (Error decoding json stream)")
+		}
+		if "" != event.Error {
+			return nil, fmt.Errorf("Loading images: %s", event.Error)
+		}
+		for _, prefix := range []string{"Loaded image: ", "Loaded image ID: "} {
+			if strings.HasPrefix(event.Stream, prefix) {
+				loaded = append(loaded, strings.TrimSpace(strings.TrimPrefix(event.Stream, prefix)))
+			}
+		}
+	}
+	return loaded, nil
+}
+
+// loadImageTar load the image of the options from Options.ImageTarPath, and check that it contained the image.
+func loadImageTar(ctx context.Context, client *docker.Client, options Options, l *eventLogger) error {
+	l.Printf("Loading %s from %s", options.Image, options.ImageTarPath)
+	archive, err := os.Open(options.ImageTarPath)
+	if nil != err {
+		return errors.Wrap(err, "Opening image archive")
+	}
+	defer archive.Close()
+	loaded, err := loadImage(ctx, client, archive)
+	if nil != err {
+		return err
+	}
+	for _, reference := range loaded {
+		if normalizedImage(reference) == normalizedImage(options.Image) {
+			return nil
+		}
+	}
+	return fmt.Errorf("Image %s not found in archive %s (Loaded: %s)", options.Image, options.ImageTarPath, strings.Join(loaded, ", "))
+}
+
+// normalizedImage return the reference with its implicit parts made explicit, so that references designating the same image are equal (eg: postgres and docker.io/library/postgres:latest).
+func normalizedImage(reference string) string {
+	if !strings.Contains(reference, "@") && repositoryOf(reference) == reference {
+		reference += ":latest"
+	}
+	return qualifiedImage(reference)
+}
@@ -0,0 +1,30 @@
+module github.com/normegil/docker/logadapter
+
+go 1.23.0
+
+require (
+	github.com/normegil/docker v0.1.0
+	github.com/rs/zerolog v1.35.1
+	github.com/sirupsen/logrus v1.10.2
+	go.uber.org/zap v1.28.0
+)
+
+require (
+	github.com/Microsoft/go-winio v0.4.5 // indirect
+	github.com/docker/distribution v2.6.2+incompatible // indirect
+	github.com/docker/docker v1.13.1 // indirect
+	github.com/docker/go-connections v0.4.0 // indirect
+	github.com/docker/go-units v0.3.2 // indirect
+	github.com/google/uuid v1.1.1 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/normegil/connectionutils v0.0.0-20181220171258-4a33da0f3393 // indirect
+	github.com/normegil/interval v0.0.0-20181220165130-6c2976dd2323 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/net v0.0.0-20171024115130-4b14673ba32b // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+replace github.com/normegil/docker => ../
@@ -0,0 +1,3 @@
+// Package logadapter map popular loggers (logrus, zap, zerolog) onto docker.Logger, with levels and structured fields (See docker.LeveledLogger).
+// It is a separate module, so that the root package does not depend on any logging library.
+package logadapter
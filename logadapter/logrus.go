@@ -0,0 +1,33 @@
+package logadapter
+
+import (
+	"github.com/normegil/docker"
+	"github.com/sirupsen/logrus"
+)
+
+// Logrus return a docker.Logger writing to the given logrus logger (a *logrus.Logger or a *logrus.Entry).
+func Logrus(logger logrus.FieldLogger) docker.LeveledLogger {
+	return logrusLogger{logger: logger}
+}
+
+type logrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+func (l logrusLogger) Printf(format string, v ...interface{}) {
+	l.logger.Infof(format, v...)
+}
+
+func (l logrusLogger) Log(level docker.Level, message string, fields docker.Fields) {
+	entry := l.logger.WithFields(logrus.Fields(fields))
+	switch level {
+	case docker.LevelDebug:
+		entry.Debug(message)
+	case docker.LevelWarn:
+		entry.Warn(message)
+	case docker.LevelError:
+		entry.Error(message)
+	default:
+		entry.Info(message)
+	}
+}
@@ -0,0 +1,42 @@
+package logadapter
+
+import (
+	"fmt"
+
+	"github.com/normegil/docker"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Zap return a docker.Logger writing to the given zap logger.
+func Zap(logger *zap.Logger) docker.LeveledLogger {
+	return zapLogger{logger: logger}
+}
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+func (l zapLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(fmt.Sprintf(format, v...))
+}
+
+func (l zapLogger) Log(level docker.Level, message string, fields docker.Fields) {
+	zapFields := make([]zap.Field, 0, len(fields))
+	for _, key := range fields.Keys() {
+		zapFields = append(zapFields, zap.Any(key, fields[key]))
+	}
+	l.logger.Log(zapLevel(level), message, zapFields...)
+}
+
+func zapLevel(level docker.Level) zapcore.Level {
+	switch level {
+	case docker.LevelDebug:
+		return zapcore.DebugLevel
+	case docker.LevelWarn:
+		return zapcore.WarnLevel
+	case docker.LevelError:
+		return zapcore.ErrorLevel
+	}
+	return zapcore.InfoLevel
+}
@@ -0,0 +1,35 @@
+package logadapter
+
+import (
+	"github.com/normegil/docker"
+	"github.com/rs/zerolog"
+)
+
+// Zerolog return a docker.Logger writing to the given zerolog logger.
+func Zerolog(logger zerolog.Logger) docker.LeveledLogger {
+	return zerologLogger{logger: logger}
+}
+
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+func (l zerologLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info().Msgf(format, v...)
+}
+
+func (l zerologLogger) Log(level docker.Level, message string, fields docker.Fields) {
+	l.logger.WithLevel(zerologLevel(level)).Fields(map[string]interface{}(fields)).Msg(message)
+}
+
+func zerologLevel(level docker.Level) zerolog.Level {
+	switch level {
+	case docker.LevelDebug:
+		return zerolog.DebugLevel
+	case docker.LevelWarn:
+		return zerolog.WarnLevel
+	case docker.LevelError:
+		return zerolog.ErrorLevel
+	}
+	return zerolog.InfoLevel
+}
@@ -1,10 +1,117 @@
 package docker
 
+import (
+	"fmt"
+	"sort"
+)
+
 // Logger is the interface to implement if you want log message to be written during the docker lifecycle.
+// Loggers also implementing LeveledLogger receive the level and the structured fields of every message.
 type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+// Level of a log message (See Options.LogLevel).
+type Level int
+
+// Levels of the log messages, from the most verbose one.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String return the name of the level (eg: "info").
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// Fields are structured data attached to a log message (eg: container, image, phase, duration).
+type Fields map[string]interface{}
+
+// Keys return the sorted keys of the fields.
+func (f Fields) Keys() []string {
+	keys := make([]string, 0, len(f))
+	for key := range f {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LeveledLogger is a Logger receiving the level and structured fields of the messages, instead of a formatted line.
+type LeveledLogger interface {
+	Logger
+	Log(level Level, message string, fields Fields)
+}
+
 type defaultLogger struct{}
 
 func (l defaultLogger) Printf(fomat string, v ...interface{}) {}
+
+// eventLogger filter the messages by level, and attach structured fields to them, before sending them to the user Logger.
+type eventLogger struct {
+	base   Logger
+	level  Level
+	fields Fields
+}
+
+// newLogger return the logger to use for the given options.
+func newLogger(options Options) *eventLogger {
+	var base Logger = &defaultLogger{}
+	if nil != options.Logger {
+		base = options.Logger
+	}
+	return &eventLogger{base: base, level: options.LogLevel, fields: Fields{"image": options.Image}}
+}
+
+// with return a logger adding the given fields to every message.
+func (l *eventLogger) with(fields Fields) *eventLogger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &eventLogger{base: l.base, level: l.level, fields: merged}
+}
+
+func (l *eventLogger) log(level Level, format string, v ...interface{}) {
+	if level < l.level {
+		return
+	}
+	if leveled, ok := l.base.(LeveledLogger); ok {
+		leveled.Log(level, fmt.Sprintf(format, v...), l.fields)
+		return
+	}
+	l.base.Printf(format, v...)
+}
+
+// Printf log the message at the info level.
+func (l *eventLogger) Printf(format string, v ...interface{}) {
+	l.log(LevelInfo, format, v...)
+}
+
+func (l *eventLogger) Debugf(format string, v ...interface{}) {
+	l.log(LevelDebug, format, v...)
+}
+
+func (l *eventLogger) Warnf(format string, v ...interface{}) {
+	l.log(LevelWarn, format, v...)
+}
+
+func (l *eventLogger) Errorf(format string, v ...interface{}) {
+	l.log(LevelError, format, v...)
+}
@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger return a Logger sending the messages, with their level and structured fields, to the given slog.Logger.
+func SlogLogger(logger *slog.Logger) LeveledLogger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l slogLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(fmt.Sprintf(format, v...))
+}
+
+func (l slogLogger) Log(level Level, message string, fields Fields) {
+	attributes := make([]slog.Attr, 0, len(fields))
+	for _, key := range fields.Keys() {
+		attributes = append(attributes, slog.Any(key, fields[key]))
+	}
+	l.logger.LogAttrs(context.Background(), slogLevel(level), message, attributes...)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
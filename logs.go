@@ -0,0 +1,202 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/pkg/errors"
+)
+
+// Log streams (See Log.Stream).
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// Log is a line written by the container on its standard output or error.
+type Log struct {
+	// Stream the line was written on (See StreamStdout and StreamStderr).
+	Stream string
+	// Timestamp at which the line was written, according to the daemon.
+	Timestamp time.Time
+	// Content of the line, without the trailing new line.
+	Content string
+}
+
+// LogConsumer receive the container logs, line by line (See Container.FollowLogs).
+type LogConsumer interface {
+	Accept(log Log)
+}
+
+// LogConsumerFunc allow to use a simple function as a LogConsumer.
+type LogConsumerFunc func(log Log)
+
+// Accept call the function itself.
+func (f LogConsumerFunc) Accept(log Log) {
+	f(log)
+}
+
+// WriterConsumer return a LogConsumer writing the stdout and stderr lines to the given writers. A nil writer discard the lines of its stream.
+func WriterConsumer(stdout, stderr io.Writer) LogConsumer {
+	return LogConsumerFunc(func(log Log) {
+		writer := stdout
+		if StreamStderr == log.Stream {
+			writer = stderr
+		}
+		if nil != writer {
+			writer.Write([]byte(log.Content + "\n"))
+		}
+	})
+}
+
+// FollowLogs send the container logs to the consumer, as they are written, until the context is done or the container exit or is removed (nil is then returned).
+// The logs stream is reopened after transient errors (See Retries.Daemon), without sending the same lines twice. FollowLogs block: it is usually called in a separate goroutine.
+func (c *Container) FollowLogs(ctx context.Context, consumer LogConsumer) error {
+	cursor := &logCursor{}
+	err := withDefaultRetries(c.options).Daemon.Retry(ctx, func() error {
+		err := c.followLogs(ctx, consumer, cursor)
+		if nil != err && nil == ctx.Err() {
+			if c.removed(ctx, err) {
+				return nil
+			}
+			c.logger.Warnf("Following logs of %s interrupted: %+v", c.Name, err)
+		}
+		return err
+	}, func(err error) bool {
+		return nil == ctx.Err()
+	})
+	if nil != ctx.Err() {
+		return nil
+	}
+	return err
+}
+
+// removed check if following the logs failed because the container was removed.
+func (c *Container) removed(ctx context.Context, err error) bool {
+	if docker.IsErrContainerNotFound(errors.Cause(err)) {
+		return true
+	}
+	_, err = c.client.ContainerInspect(ctx, c.Identifier)
+	return docker.IsErrContainerNotFound(err)
+}
+
+// followLogs stream the logs from the cursor, updating it with every line sent to the consumer. It return nil once the container exited.
+func (c *Container) followLogs(ctx context.Context, consumer LogConsumer, cursor *logCursor) error {
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+	}
+	if !cursor.last.IsZero() {
+		// The lines written at the timestamp of the cursor are sent again by the daemon
+		options.Since = cursor.last.Format(time.RFC3339Nano)
+		cursor.skip = cursor.sent
+	}
+	reader, err := c.client.ContainerLogs(ctx, c.Identifier, options)
+	if nil != err {
+		return errors.Wrap(err, "Opening logs stream")
+	}
+	defer reader.Close()
+
+	stdout := &logWriter{stream: StreamStdout, consumer: consumer, cursor: cursor}
+	stderr := &logWriter{stream: StreamStderr, consumer: consumer, cursor: cursor}
+	if c.options.Tty {
+		// Without multiplexing, the output of a TTY cannot be split between stdout and stderr
+		_, err = io.Copy(stdout, reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	}
+	stdout.flush()
+	stderr.flush()
+	if nil != err {
+		return errors.Wrap(err, "Reading logs stream")
+	}
+
+	inspected, err := c.client.ContainerInspect(ctx, c.Identifier)
+	if nil != err {
+		return errors.Wrap(err, "Inspecting container")
+	}
+	if nil != inspected.State && inspected.State.Running {
+		return errors.New("Logs stream closed while container is running")
+	}
+	return nil
+}
+
+// logCursor is the position of the lines sent to the consumer, shared by the stdout and stderr streams. Lines can share a timestamp: they are counted, to skip only the ones already sent when the stream is reopened.
+type logCursor struct {
+	// last is the timestamp of the last line sent.
+	last time.Time
+	// sent is the number of lines sent with the last timestamp.
+	sent int
+	// skip is the number of lines with the last timestamp to skip, as they were sent before the stream was reopened.
+	skip int
+}
+
+// accept check if the line written at the given timestamp must be sent, and move the cursor after it.
+func (c *logCursor) accept(timestamp time.Time) bool {
+	switch {
+	case timestamp.Before(c.last):
+		return false
+	case timestamp.Equal(c.last):
+		if 0 < c.skip {
+			c.skip--
+			return false
+		}
+		c.sent++
+	default:
+		c.last = timestamp
+		c.sent = 1
+		c.skip = 0
+	}
+	return true
+}
+
+// logWriter split a demultiplexed logs stream into timestamped lines.
+type logWriter struct {
+	stream   string
+	consumer LogConsumer
+	cursor   *logCursor
+	buffer   bytes.Buffer
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buffer.Write(p)
+	for {
+		index := bytes.IndexByte(w.buffer.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+		line := string(w.buffer.Next(index + 1))
+		w.send(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// flush send the last line of the stream, if it was not terminated by a new line.
+func (w *logWriter) flush() {
+	if 0 != w.buffer.Len() {
+		w.send(w.buffer.String())
+		w.buffer.Reset()
+	}
+}
+
+// send parse the timestamp prefixing the line, and send it to the consumer unless it was already sent before the stream was reopened.
+func (w *logWriter) send(line string) {
+	log := Log{Stream: w.stream, Content: line}
+	if separator := strings.IndexByte(line, ' '); separator > 0 {
+		if timestamp, err := time.Parse(time.RFC3339Nano, line[:separator]); nil == err {
+			if !w.cursor.accept(timestamp) {
+				return
+			}
+			log.Timestamp = timestamp
+			log.Content = line[separator+1:]
+		}
+	}
+	w.consumer.Accept(log)
+}
@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"expvar"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metrics receive the measures of the container startups (See Options.Metrics), so that large test suites can track and alert on slowly degrading startup times.
+// ExpvarMetrics publish them with expvar, and the github.com/normegil/docker/prommetrics module with Prometheus. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// ImagePulled is called after each pull of an image from its registry (images already available locally are not pulled). Failed is true if the pull failed.
+	ImagePulled(image string, duration time.Duration, failed bool)
+	// ContainerStarted is called at the end of each container startup, from the pull to the readiness. Failed is true if the container could not be started.
+	ContainerStarted(image string, duration time.Duration, failed bool)
+	// ReadinessFailed is called when a started container was not ready within its startup timeout (See Options.StartupTimeout).
+	ReadinessFailed(image string)
+}
+
+// DurationBuckets are the upper bounds, in seconds, of the histograms of pull and startup durations.
+var DurationBuckets = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 120, 300}
+
+var expvarOnce sync.Once
+var sharedExpvarMetrics *expvarMetrics
+
+// ExpvarMetrics return the Metrics publishing, with expvar (See /debug/vars), the variables:
+//   - docker.pulls and docker.startups: number of pulls and startups, by result (success, failure).
+//   - docker.pull_duration and docker.startup_duration: histograms of the durations (See DurationBuckets), with their count and sum in seconds.
+//   - docker.readiness_failures: number of containers not ready within their startup timeout.
+//
+// The variables are published once per process: every call return the same Metrics.
+func ExpvarMetrics() Metrics {
+	expvarOnce.Do(func() {
+		sharedExpvarMetrics = &expvarMetrics{
+			pulls:             expvar.NewMap("docker.pulls"),
+			pullDuration:      newExpvarHistogram("docker.pull_duration"),
+			startups:          expvar.NewMap("docker.startups"),
+			startupDuration:   newExpvarHistogram("docker.startup_duration"),
+			readinessFailures: expvar.NewInt("docker.readiness_failures"),
+		}
+	})
+	return sharedExpvarMetrics
+}
+
+type expvarMetrics struct {
+	pulls             *expvar.Map
+	pullDuration      *expvarHistogram
+	startups          *expvar.Map
+	startupDuration   *expvarHistogram
+	readinessFailures *expvar.Int
+}
+
+func (m *expvarMetrics) ImagePulled(image string, duration time.Duration, failed bool) {
+	m.pulls.Add(result(failed), 1)
+	m.pullDuration.observe(duration)
+}
+
+func (m *expvarMetrics) ContainerStarted(image string, duration time.Duration, failed bool) {
+	m.startups.Add(result(failed), 1)
+	m.startupDuration.observe(duration)
+}
+
+func (m *expvarMetrics) ReadinessFailed(image string) {
+	m.readinessFailures.Add(1)
+}
+
+// result return the label of the result of an operation.
+func result(failed bool) string {
+	if failed {
+		return "failure"
+	}
+	return "success"
+}
+
+// expvarHistogram is a cumulative histogram of durations, published as an expvar map: one counter per bucket (le_<bound>), and the count and sum of the observed durations.
+type expvarHistogram struct {
+	values *expvar.Map
+}
+
+func newExpvarHistogram(name string) *expvarHistogram {
+	values := expvar.NewMap(name)
+	for _, bound := range DurationBuckets {
+		values.Add(bucketKey(bound), 0)
+	}
+	values.Add("le_inf", 0)
+	values.Add("count", 0)
+	values.AddFloat("sum", 0)
+	return &expvarHistogram{values: values}
+}
+
+func (h *expvarHistogram) observe(duration time.Duration) {
+	seconds := duration.Seconds()
+	for _, bound := range DurationBuckets {
+		if seconds <= bound {
+			h.values.Add(bucketKey(bound), 1)
+		}
+	}
+	h.values.Add("le_inf", 1)
+	h.values.Add("count", 1)
+	h.values.AddFloat("sum", seconds)
+}
+
+func bucketKey(bound float64) string {
+	return "le_" + strconv.FormatFloat(bound, 'f', -1, 64)
+}
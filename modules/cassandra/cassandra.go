@@ -0,0 +1,138 @@
+// Package cassandra start a single-node Cassandra or ScyllaDB cluster for integration tests.
+// To create the container, see the New() function.
+package cassandra
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/normegil/docker/modules/internal/initscripts"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "cassandra:4.1"
+const defaultHeap = "512M"
+const defaultStartupTimeout = 3 * time.Minute
+
+// datacenter is the name of the datacenter of the node, for both Cassandra and ScyllaDB images.
+const datacenter = "datacenter1"
+
+// scriptsDirectory is the directory in which the init scripts are copied, to be run through cqlsh.
+const scriptsDirectory = "/docker-entrypoint-initdb.d"
+
+var cqlPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         9042,
+	ExternalInterval: "[19042;20042]",
+}
+
+// Options to configure the cluster.
+type Options struct {
+	// Image of the node. Default to cassandra:4.1. ScyllaDB images (eg: scylladb/scylla:6.1) are supported.
+	Image string
+	// Heap is the memory of the node (eg: 1G): the JVM heap for Cassandra, the memory reserved by ScyllaDB. Default to 512M, which keep the node within the memory of CI runners.
+	Heap string
+	// InitScripts are host paths of CQL scripts run, in order, once the node is ready (eg: keyspaces, tables, seed data).
+	InitScripts []string
+	// StartupTimeout is the maximum time to wait for the node to be ready, excluding the init scripts. Default to 3 minutes.
+	StartupTimeout time.Duration
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running single-node cluster.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start a single-node cluster, waiting for it to answer CQL queries, then run the init scripts. The returned function stop and remove the container.
+// The node skip the delays meant to let a multi-node cluster settle, which would only slow the tests down.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+	files, err := initscripts.Files(scriptsDirectory, options.InitScripts)
+	if nil != err {
+		return nil, nil, err
+	}
+
+	containerOptions := core.Options{
+		Name:           "cassandra",
+		Image:          options.Image,
+		Ports:          []docker.PortBinding{cqlPort},
+		Files:          files,
+		StartupTimeout: options.StartupTimeout,
+		WaitStrategy: docker.ForAll(
+			docker.ForListeningPort(cqlPort),
+			docker.ForExec(cqlsh("-e", "SELECT release_version FROM system.local")...),
+		),
+		Logger: options.Logger,
+	}
+	if isScylla(options.Image) {
+		containerOptions.Name = "scylla"
+		containerOptions.Cmd = []string{"--smp", "1", "--memory", options.Heap, "--overprovisioned", "1", "--developer-mode", "1", "--skip-wait-for-gossip-to-settle", "0"}
+	} else {
+		containerOptions.EnvironmentVariables = map[string]string{
+			"MAX_HEAP_SIZE": options.Heap,
+			"HEAP_NEWSIZE":  "100M",
+			"CASSANDRA_DC":  datacenter,
+			// GossipingPropertyFileSnitch is required to name the datacenter
+			"CASSANDRA_ENDPOINT_SNITCH": "GossipingPropertyFileSnitch",
+			"JVM_EXTRA_OPTS":            "-Dcassandra.skip_wait_for_gossip_to_settle=0 -Dcassandra.initial_token=0",
+		}
+	}
+	c, err := core.Start(containerOptions)
+	if nil != err {
+		return nil, nil, err
+	}
+	closer := func() error {
+		return c.Terminate(context.Background())
+	}
+
+	for _, file := range files {
+		code, output, err := c.Exec(context.Background(), cqlsh("-f", file.ContainerPath)...)
+		if nil == err && 0 != code {
+			err = errors.Errorf("Exit code %d: %s", code, strings.TrimSpace(string(output)))
+		}
+		if nil != err {
+			closer()
+			return nil, nil, errors.Wrapf(err, "Running init script %s", filepath.Base(file.ContainerPath))
+		}
+	}
+	return &Container{ContainerInfo: c.ContainerInfo, options: options}, closer, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.Heap {
+		options.Heap = defaultHeap
+	}
+	if 0 == options.StartupTimeout {
+		options.StartupTimeout = defaultStartupTimeout
+	}
+	return options
+}
+
+func isScylla(image string) bool {
+	return strings.Contains(image, "scylla")
+}
+
+// cqlsh return the command running cqlsh, inside the container, with the given arguments.
+func cqlsh(arguments ...string) []string {
+	return append([]string{"cqlsh", "127.0.0.1", "9042"}, arguments...)
+}
+
+// ContactPoints return the "host:port" addresses with which the drivers discover the cluster.
+func (c Container) ContactPoints() []string {
+	return []string{c.Endpoint(cqlPort)}
+}
+
+// Datacenter return the name of the datacenter of the node, required by the datacenter-aware load balancing policies of the drivers.
+func (c Container) Datacenter() string {
+	return datacenter
+}
@@ -0,0 +1,169 @@
+// Package elasticsearch start a single-node Elasticsearch or OpenSearch cluster for integration tests.
+// To create the container, see the New() function.
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "docker.elastic.co/elasticsearch/elasticsearch:8.15.3"
+const defaultPassword = "changeme"
+const defaultHeap = "512m"
+const defaultStartupTimeout = 2 * time.Minute
+
+// username is the built-in superuser of Elasticsearch, whose password is set by the image.
+const username = "elastic"
+
+// healthPath return once the cluster is at least yellow (a single node cannot allocate replicas), or after a second.
+const healthPath = "/_cluster/health?wait_for_status=yellow&timeout=1s"
+
+var httpPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         9200,
+	ExternalInterval: "[19200;20200]",
+}
+
+// Options to configure the cluster.
+type Options struct {
+	// Image of the node. Default to docker.elastic.co/elasticsearch/elasticsearch:8.15.3. OpenSearch images (eg: opensearchproject/opensearch:2) are supported, without Security.
+	Image string
+	// Security enable the authentication (xpack.security), with the elastic user. HTTP stays unencrypted, so that the tests don't need the generated certificates.
+	Security bool
+	// Password of the elastic user, if Security is enabled. Default to changeme.
+	Password string
+	// Heap is the size of the JVM heap (eg: 1g). Default to 512m, which is enough for tests and keep the node within the memory of CI runners.
+	Heap string
+	// Settings are additional node settings (eg: action.auto_create_index=false).
+	Settings map[string]string
+	// StartupTimeout is the maximum time to wait for the cluster to be ready. Default to 2 minutes.
+	StartupTimeout time.Duration
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// ClientConfig gather what an Elasticsearch client need to connect to the cluster. Username and Password are empty without Security.
+type ClientConfig struct {
+	Addresses []string
+	Username  string
+	Password  string
+}
+
+// Container is a running single-node cluster.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start a single-node cluster, waiting for its health to be at least yellow. The returned function stop and remove the container.
+// The node doesn't use memory-mapped files, so that it doesn't require vm.max_map_count to be raised on the host: this setting is not namespaced, and cannot be set per container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+	if isOpenSearch(options.Image) && options.Security {
+		return nil, nil, errors.Errorf("Security is not supported with OpenSearch images: %s", options.Image)
+	}
+
+	env := map[string]string{
+		"discovery.type":        "single-node",
+		"node.store.allow_mmap": "false",
+		// CI runners often have nearly full disks, on which the node would refuse to allocate shards
+		"cluster.routing.allocation.disk.threshold_enabled": "false",
+	}
+	if isOpenSearch(options.Image) {
+		env["OPENSEARCH_JAVA_OPTS"] = heapOptions(options.Heap)
+		env["DISABLE_SECURITY_PLUGIN"] = "true"
+		env["DISABLE_INSTALL_DEMO_CONFIG"] = "true"
+	} else {
+		env["ES_JAVA_OPTS"] = heapOptions(options.Heap)
+		env["xpack.security.enabled"] = strconv.FormatBool(options.Security)
+		if options.Security {
+			env["ELASTIC_PASSWORD"] = options.Password
+			env["xpack.security.http.ssl.enabled"] = "false"
+			env["xpack.security.transport.ssl.enabled"] = "false"
+		}
+	}
+	for key, value := range options.Settings {
+		env[key] = value
+	}
+
+	wait := docker.ForHTTP(httpPort, healthPath, healthy)
+	if options.Security {
+		// The health endpoint require authentication: it is checked from inside the container
+		wait = docker.ForExec("curl", "--silent", "--fail", "--user", username+":"+options.Password, "http://localhost:9200"+healthPath)
+	}
+	started, err := core.Start(core.Options{
+		Name:                 "elasticsearch",
+		Image:                options.Image,
+		Ports:                []docker.PortBinding{httpPort},
+		EnvironmentVariables: env,
+		Ulimits: []docker.Ulimit{
+			{Name: "nofile", Soft: 65535, Hard: 65535},
+			{Name: "memlock", Soft: -1, Hard: -1},
+		},
+		StartupTimeout: options.StartupTimeout,
+		WaitStrategy:   wait,
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: started.ContainerInfo, options: options}, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.Password {
+		options.Password = defaultPassword
+	}
+	if "" == options.Heap {
+		options.Heap = defaultHeap
+	}
+	if 0 == options.StartupTimeout {
+		options.StartupTimeout = defaultStartupTimeout
+	}
+	return options
+}
+
+func isOpenSearch(image string) bool {
+	return strings.Contains(image, "opensearch")
+}
+
+func heapOptions(heap string) string {
+	return "-Xms" + heap + " -Xmx" + heap
+}
+
+func healthy(status int, body []byte) bool {
+	var health struct {
+		Status string `json:"status"`
+	}
+	if 200 != status || nil != json.Unmarshal(body, &health) {
+		return false
+	}
+	return "green" == health.Status || "yellow" == health.Status
+}
+
+// URL return the HTTP URL of the cluster (eg: http://127.0.0.1:19200).
+func (c Container) URL() string {
+	return "http://" + c.Endpoint(httpPort)
+}
+
+// ClientConfig return the configuration to use with an Elasticsearch or OpenSearch client.
+func (c Container) ClientConfig() ClientConfig {
+	config := ClientConfig{Addresses: []string{c.URL()}}
+	if c.options.Security {
+		config.Username = username
+		config.Password = c.options.Password
+	}
+	return config
+}
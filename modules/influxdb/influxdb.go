@@ -0,0 +1,131 @@
+// Package influxdb start an InfluxDB 2.x server for integration tests, with its organization, bucket and token already set up.
+// To create the container, see the New() function.
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+)
+
+const defaultImage = "influxdb:2.7"
+const defaultUsername = "admin"
+const defaultPassword = "password"
+const defaultOrganization = "test"
+const defaultBucket = "test"
+const defaultToken = "test-token"
+const startupTimeout = 30 * time.Second
+
+var influxPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         8086,
+	ExternalInterval: "[18086;19086]",
+}
+
+// Options to configure the InfluxDB server.
+type Options struct {
+	// Image of the InfluxDB server. Must be a 2.x image. Default to influxdb:2.7.
+	Image string
+	// Username and Password of the initial user. Default to admin/password.
+	Username string
+	Password string
+	// Organization and Bucket created at startup. Default to test/test.
+	Organization string
+	Bucket       string
+	// Token is the admin token created at startup. Default to test-token.
+	Token string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// ClientConfig gather what an InfluxDB client need to connect to the server.
+type ClientConfig struct {
+	URL          string
+	Token        string
+	Organization string
+	Bucket       string
+}
+
+// Container is a running InfluxDB server.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start an InfluxDB server, waiting for its health endpoint to pass. The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+
+	started, err := core.Start(core.Options{
+		Name:  "influxdb",
+		Image: options.Image,
+		Ports: []docker.PortBinding{influxPort},
+		EnvironmentVariables: map[string]string{
+			"DOCKER_INFLUXDB_INIT_MODE":        "setup",
+			"DOCKER_INFLUXDB_INIT_USERNAME":    options.Username,
+			"DOCKER_INFLUXDB_INIT_PASSWORD":    options.Password,
+			"DOCKER_INFLUXDB_INIT_ORG":         options.Organization,
+			"DOCKER_INFLUXDB_INIT_BUCKET":      options.Bucket,
+			"DOCKER_INFLUXDB_INIT_ADMIN_TOKEN": options.Token,
+		},
+		StartupTimeout: startupTimeout,
+		WaitStrategy:   docker.ForHTTP(influxPort, "/health", healthy),
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: started.ContainerInfo, options: options}, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.Username {
+		options.Username = defaultUsername
+	}
+	if "" == options.Password {
+		options.Password = defaultPassword
+	}
+	if "" == options.Organization {
+		options.Organization = defaultOrganization
+	}
+	if "" == options.Bucket {
+		options.Bucket = defaultBucket
+	}
+	if "" == options.Token {
+		options.Token = defaultToken
+	}
+	return options
+}
+
+func healthy(status int, body []byte) bool {
+	var health struct {
+		Status string `json:"status"`
+	}
+	if 200 != status || nil != json.Unmarshal(body, &health) {
+		return false
+	}
+	return "pass" == health.Status
+}
+
+// URL return the HTTP URL of the server (eg: http://127.0.0.1:18086).
+func (c Container) URL() string {
+	return "http://" + c.Endpoint(influxPort)
+}
+
+// ClientConfig return the configuration to use with an InfluxDB client.
+func (c Container) ClientConfig() ClientConfig {
+	return ClientConfig{
+		URL:          c.URL(),
+		Token:        c.options.Token,
+		Organization: c.options.Organization,
+		Bucket:       c.options.Bucket,
+	}
+}
@@ -0,0 +1,49 @@
+// Package initscripts gather the init scripts given to the modules, as files to copy in their containers.
+package initscripts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/normegil/docker"
+	"github.com/pkg/errors"
+)
+
+// Files return the scripts, read from the host, as files of the given container directory. Shell scripts (.sh) are executable, other scripts keep the default mode of File. Images usually run them in alphabetical order: they are prefixed by their index to keep the specified order.
+// If extensions are specified (eg: .sql, .sh), the scripts must have one of them.
+func Files(directory string, scripts []string, extensions ...string) ([]docker.File, error) {
+	files := make([]docker.File, 0, len(scripts))
+	for i, script := range scripts {
+		name := filepath.Base(script)
+		if !supported(name, extensions) {
+			return nil, errors.Errorf("Unsupported init script (expected %s): %s", strings.Join(extensions, ", "), script)
+		}
+		content, err := ioutil.ReadFile(script)
+		if nil != err {
+			return nil, errors.Wrapf(err, "Reading init script %s", script)
+		}
+		file := docker.File{
+			ContainerPath: fmt.Sprintf("%s/%03d-%s", directory, i, name),
+			Content:       content,
+		}
+		if strings.HasSuffix(name, ".sh") {
+			file.Mode = 0755
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func supported(name string, extensions []string) bool {
+	if 0 == len(extensions) {
+		return true
+	}
+	for _, extension := range extensions {
+		if strings.HasSuffix(name, extension) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+// Package jaeger start a Jaeger all-in-one server, receiving traces through OTLP, so instrumentation can be asserted end-to-end in tests.
+// To create the container, see the New() function.
+package jaeger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "jaegertracing/all-in-one:1.57"
+const startupTimeout = 30 * time.Second
+const pollInterval = 250 * time.Millisecond
+
+var queryPort = docker.PortBinding{Protocol: "tcp", Internal: 16686, ExternalInterval: "[16686;17686]"}
+var otlpGRPCPort = docker.PortBinding{Protocol: "tcp", Internal: 4317, ExternalInterval: "[14317;15317]"}
+var otlpHTTPPort = docker.PortBinding{Protocol: "tcp", Internal: 4318, ExternalInterval: "[15318;16318]"}
+var adminPort = docker.PortBinding{Protocol: "tcp", Internal: 14269, ExternalInterval: "[24269;25269]"}
+
+// Options to configure the Jaeger server.
+type Options struct {
+	// Image of the Jaeger server. Default to jaegertracing/all-in-one:1.57.
+	Image string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running Jaeger server.
+type Container struct {
+	docker.ContainerInfo
+}
+
+// Trace is a trace received by Jaeger, as returned by its query API.
+type Trace struct {
+	TraceID string `json:"traceID"`
+	Spans   []Span `json:"spans"`
+}
+
+// Span is a span of a Trace.
+type Span struct {
+	TraceID       string `json:"traceID"`
+	SpanID        string `json:"spanID"`
+	OperationName string `json:"operationName"`
+	// StartTime is in microseconds since epoch.
+	StartTime int64 `json:"startTime"`
+	// Duration is in microseconds.
+	Duration int64 `json:"duration"`
+	Tags     []Tag `json:"tags"`
+}
+
+// Tag is a key/value attribute of a Span.
+type Tag struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// New start a Jaeger server, with OTLP receivers enabled, waiting for it to be healthy. The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+
+	started, err := core.Start(core.Options{
+		Name:  "jaeger",
+		Image: options.Image,
+		Ports: []docker.PortBinding{queryPort, otlpGRPCPort, otlpHTTPPort, adminPort},
+		EnvironmentVariables: map[string]string{
+			"COLLECTOR_OTLP_ENABLED": "true",
+		},
+		StartupTimeout: startupTimeout,
+		WaitStrategy: docker.ForAll(
+			docker.ForHTTP(adminPort, "/", nil),
+			docker.ForHTTP(queryPort, "/api/services", nil),
+		),
+		Logger: options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: started.ContainerInfo}, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+// OTLPGRPCEndpoint return the "host:port" address of the OTLP gRPC receiver.
+func (c Container) OTLPGRPCEndpoint() string {
+	return c.Endpoint(otlpGRPCPort)
+}
+
+// OTLPHTTPEndpoint return the base URL of the OTLP HTTP receiver (eg: http://127.0.0.1:15318). Traces are sent to /v1/traces.
+func (c Container) OTLPHTTPEndpoint() string {
+	return "http://" + c.Endpoint(otlpHTTPPort)
+}
+
+// QueryURL return the base URL of the Jaeger UI and query API.
+func (c Container) QueryURL() string {
+	return "http://" + c.Endpoint(queryPort)
+}
+
+// Services return the names of the services which sent traces.
+func (c Container) Services(ctx context.Context) ([]string, error) {
+	var response struct {
+		Data []string `json:"data"`
+	}
+	if err := c.query(ctx, "/api/services", &response); nil != err {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// Traces return the traces received for the given service.
+func (c Container) Traces(ctx context.Context, service string) ([]Trace, error) {
+	var response struct {
+		Data []Trace `json:"data"`
+	}
+	if err := c.query(ctx, "/api/traces?service="+url.QueryEscape(service), &response); nil != err {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// WaitForTraces wait for at least count traces to be received for the given service, as traces are usually exported asynchronously.
+func (c Container) WaitForTraces(ctx context.Context, service string, count int) ([]Trace, error) {
+	for {
+		traces, err := c.Traces(ctx, service)
+		if nil == err && len(traces) >= count {
+			return traces, nil
+		}
+		select {
+		case <-ctx.Done():
+			return traces, fmt.Errorf("Received %d/%d trace(s) for %s: %+v", len(traces), count, service, ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (c Container) query(ctx context.Context, path string, result interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, c.QueryURL()+path, nil)
+	if nil != err {
+		return errors.Wrapf(err, "Creating request %s", path)
+	}
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if nil != err {
+		return errors.Wrapf(err, "Querying %s", path)
+	}
+	defer response.Body.Close()
+	if http.StatusOK != response.StatusCode {
+		return fmt.Errorf("Querying %s: unexpected status %s", path, response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(result); nil != err {
+		return errors.Wrapf(err, "Decoding response of %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Package keycloak start a Keycloak server in development mode for OpenID Connect integration tests.
+// To create the container, see the New() function.
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "quay.io/keycloak/keycloak:25.0"
+const defaultAdminUser = "admin"
+const defaultAdminPassword = "admin"
+const defaultStartupTimeout = 2 * time.Minute
+
+// masterRealm is the realm of the administrators, always present.
+const masterRealm = "master"
+
+// importDirectory is the directory from which the server import realms at startup (See the --import-realm option).
+const importDirectory = "/opt/keycloak/data/import/"
+
+var httpPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         8080,
+	ExternalInterval: "[18080;19080]",
+}
+
+// Options to configure the Keycloak server.
+type Options struct {
+	// Image of the Keycloak server. Default to quay.io/keycloak/keycloak:25.0.
+	Image string
+	// AdminUser and AdminPassword are the credentials of the administrator, in the master realm. Default to admin/admin.
+	AdminUser     string
+	AdminPassword string
+	// RealmFile, if specified, is the host path of a realm export (JSON), imported at startup. Issuer() and DiscoveryURL() then refer to this realm, and the server is ready once it is served.
+	RealmFile string
+	// StartupTimeout is the maximum time to wait for the server to be ready, including the realm import. Default to 2 minutes.
+	StartupTimeout time.Duration
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running Keycloak server.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+	// realm is the name of the imported realm, or the master realm.
+	realm string
+}
+
+// New start a Keycloak server, waiting for the discovery document of the realm to be served. The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+	c := &Container{options: options, realm: masterRealm}
+
+	var files []docker.File
+	if "" != options.RealmFile {
+		content, err := ioutil.ReadFile(options.RealmFile)
+		if nil != err {
+			return nil, nil, errors.Wrapf(err, "Reading realm %s", options.RealmFile)
+		}
+		var realm struct {
+			Realm string `json:"realm"`
+		}
+		if err := json.Unmarshal(content, &realm); nil != err {
+			return nil, nil, errors.Wrapf(err, "Parsing realm %s", options.RealmFile)
+		}
+		if "" == realm.Realm {
+			return nil, nil, errors.Errorf("Realm name not found in %s", options.RealmFile)
+		}
+		c.realm = realm.Realm
+		files = append(files, docker.File{ContainerPath: importDirectory + filepath.Base(options.RealmFile), Content: content, Mode: 0644})
+	}
+
+	started, err := core.Start(core.Options{
+		Name:  "keycloak",
+		Image: options.Image,
+		Cmd:   []string{"start-dev", "--import-realm"},
+		Ports: []docker.PortBinding{httpPort},
+		EnvironmentVariables: map[string]string{
+			// Keycloak 26 renamed the variables of the initial administrator
+			"KEYCLOAK_ADMIN":              options.AdminUser,
+			"KEYCLOAK_ADMIN_PASSWORD":     options.AdminPassword,
+			"KC_BOOTSTRAP_ADMIN_USERNAME": options.AdminUser,
+			"KC_BOOTSTRAP_ADMIN_PASSWORD": options.AdminPassword,
+		},
+		Files:          files,
+		StartupTimeout: options.StartupTimeout,
+		WaitStrategy:   docker.ForHTTP(httpPort, discoveryPath(c.realm), nil),
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	c.ContainerInfo = started.ContainerInfo
+	return c, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.AdminUser {
+		options.AdminUser = defaultAdminUser
+	}
+	if "" == options.AdminPassword {
+		options.AdminPassword = defaultAdminPassword
+	}
+	if 0 == options.StartupTimeout {
+		options.StartupTimeout = defaultStartupTimeout
+	}
+	return options
+}
+
+func realmPath(realm string) string {
+	return "/realms/" + realm
+}
+
+func discoveryPath(realm string) string {
+	return realmPath(realm) + "/.well-known/openid-configuration"
+}
+
+// URL return the HTTP URL of the server (eg: http://127.0.0.1:18080).
+func (c Container) URL() string {
+	return "http://" + c.Endpoint(httpPort)
+}
+
+// Realm return the name of the imported realm (See Options.RealmFile), or master if no realm was imported.
+func (c Container) Realm() string {
+	return c.realm
+}
+
+// Issuer return the issuer URL of the realm, as found in the tokens it issue.
+func (c Container) Issuer() string {
+	return c.URL() + realmPath(c.realm)
+}
+
+// DiscoveryURL return the URL of the OpenID Connect discovery document of the realm.
+func (c Container) DiscoveryURL() string {
+	return c.URL() + discoveryPath(c.realm)
+}
+
+// AdminCredentials return the user and password of the administrator, in the master realm.
+func (c Container) AdminCredentials() (string, string) {
+	return c.options.AdminUser, c.options.AdminPassword
+}
@@ -0,0 +1,147 @@
+package ldap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/normegil/docker"
+)
+
+const bindTimeout = 2 * time.Second
+
+// ForBind wait for a simple bind with the given DN and password to succeed on the given port.
+func ForBind(binding docker.PortBinding, dn string, password string) docker.WaitStrategy {
+	return docker.WaitStrategyFunc(func(ctx context.Context, target docker.WaitTarget) error {
+		hostport := target.Endpoint(binding)
+		var lastErr error
+		for {
+			if lastErr = bind(ctx, hostport, dn, password); nil == lastErr {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("Could not bind to %s as %s: %v", hostport, dn, lastErr)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	})
+}
+
+// bind send a LDAPv3 simple bind request, and check that the response result code is success.
+func bind(ctx context.Context, hostport string, dn string, password string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostport)
+	if nil != err {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(bindTimeout))
+
+	request := berElement(0x60, concat(
+		berElement(0x02, []byte{3}),
+		berElement(0x04, []byte(dn)),
+		berElement(0x80, []byte(password)),
+	))
+	message := berElement(0x30, concat(berElement(0x02, []byte{1}), request))
+	if _, err := conn.Write(message); nil != err {
+		return err
+	}
+
+	content, err := readElement(conn, 0x30)
+	if nil != err {
+		return err
+	}
+	_, content, err = splitElement(content, 0x02)
+	if nil != err {
+		return err
+	}
+	response, _, err := splitElement(content, 0x61)
+	if nil != err {
+		return err
+	}
+	resultCode, _, err := splitElement(response, 0x0a)
+	if nil != err {
+		return err
+	}
+	if 1 != len(resultCode) || 0 != resultCode[0] {
+		return fmt.Errorf("Bind failed with result code %v", resultCode)
+	}
+	return nil
+}
+
+func concat(parts ...[]byte) []byte {
+	result := make([]byte, 0)
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
+
+func berElement(tag byte, content []byte) []byte {
+	return append(append([]byte{tag}, berLength(len(content))...), content...)
+}
+
+func berLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	bytes := make([]byte, 0)
+	for ; length > 0; length >>= 8 {
+		bytes = append([]byte{byte(length)}, bytes...)
+	}
+	return append([]byte{0x80 | byte(len(bytes))}, bytes...)
+}
+
+// readElement read a whole BER element with the given tag from the reader, and return its content.
+func readElement(reader io.Reader, tag byte) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); nil != err {
+		return nil, err
+	}
+	if tag != header[0] {
+		return nil, fmt.Errorf("Unexpected tag %#x (Expected: %#x)", header[0], tag)
+	}
+	length := int(header[1])
+	if length >= 0x80 {
+		lengthBytes := make([]byte, length&0x7f)
+		if _, err := io.ReadFull(reader, lengthBytes); nil != err {
+			return nil, err
+		}
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); nil != err {
+		return nil, err
+	}
+	return content, nil
+}
+
+// splitElement decode the BER element with the given tag at the start of data, and return its content and the remaining data.
+func splitElement(data []byte, tag byte) ([]byte, []byte, error) {
+	if len(data) < 2 || tag != data[0] {
+		return nil, nil, fmt.Errorf("Expected tag %#x", tag)
+	}
+	length := int(data[1])
+	offset := 2
+	if length >= 0x80 {
+		size := length & 0x7f
+		if len(data) < offset+size {
+			return nil, nil, fmt.Errorf("Truncated length for tag %#x", tag)
+		}
+		length = 0
+		for _, b := range data[offset : offset+size] {
+			length = length<<8 | int(b)
+		}
+		offset += size
+	}
+	if len(data) < offset+length {
+		return nil, nil, fmt.Errorf("Truncated content for tag %#x", tag)
+	}
+	return data[offset : offset+length], data[offset+length:], nil
+}
@@ -0,0 +1,128 @@
+// Package ldap start an OpenLDAP server (osixia/openldap) for authentication integration tests.
+// To create the container, see the New() function.
+package ldap
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+)
+
+const defaultImage = "osixia/openldap:1.5.0"
+const defaultDomain = "example.org"
+const defaultOrganisation = "Example"
+const defaultAdminPassword = "admin"
+const ldifDirectory = "/container/service/slapd/assets/config/bootstrap/ldif/custom/"
+const startupTimeout = 30 * time.Second
+
+var ldapPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         389,
+	ExternalInterval: "[1389;2389]",
+}
+
+// Options to configure the OpenLDAP server.
+type Options struct {
+	// Image of the OpenLDAP server. Default to osixia/openldap:1.5.0.
+	Image string
+	// Domain of the directory (eg: example.org for a base DN dc=example,dc=org). Default to example.org.
+	Domain string
+	// Organisation name. Default to Example.
+	Organisation string
+	// AdminPassword is the password of the admin user (cn=admin,<BaseDN>). Default to admin.
+	AdminPassword string
+	// LDIFs to load at startup, indexed by file name (eg: "users.ldif").
+	LDIFs map[string]string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running OpenLDAP server.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start an OpenLDAP server, waiting for the admin user to be able to bind. The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+	c := &Container{options: options}
+
+	files := make([]docker.File, 0, len(options.LDIFs))
+	for name, content := range options.LDIFs {
+		files = append(files, docker.File{
+			ContainerPath: ldifDirectory + name,
+			Content:       []byte(content),
+		})
+	}
+
+	started, err := core.Start(core.Options{
+		Name:  "openldap",
+		Image: options.Image,
+		Ports: []docker.PortBinding{ldapPort},
+		EnvironmentVariables: map[string]string{
+			"LDAP_DOMAIN":         options.Domain,
+			"LDAP_ORGANISATION":   options.Organisation,
+			"LDAP_ADMIN_PASSWORD": options.AdminPassword,
+		},
+		Files:          files,
+		StartupTimeout: startupTimeout,
+		WaitStrategy:   ForBind(ldapPort, c.AdminDN(), options.AdminPassword),
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	c.ContainerInfo = started.ContainerInfo
+	return c, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.Domain {
+		options.Domain = defaultDomain
+	}
+	if "" == options.Organisation {
+		options.Organisation = defaultOrganisation
+	}
+	if "" == options.AdminPassword {
+		options.AdminPassword = defaultAdminPassword
+	}
+	return options
+}
+
+// URL return the URL of the server (eg: ldap://127.0.0.1:1389).
+func (c Container) URL() string {
+	return "ldap://" + c.Endpoint(ldapPort)
+}
+
+// Port return the port on which the server is reachable (See docker.ContainerInfo.Port).
+func (c Container) Port() int {
+	return c.ContainerInfo.Port(ldapPort)
+}
+
+// BaseDN return the base DN of the directory (eg: dc=example,dc=org).
+func (c Container) BaseDN() string {
+	components := strings.Split(c.options.Domain, ".")
+	for i, component := range components {
+		components[i] = "dc=" + component
+	}
+	return strings.Join(components, ",")
+}
+
+// AdminDN return the DN of the admin user.
+func (c Container) AdminDN() string {
+	return "cn=admin," + c.BaseDN()
+}
+
+// AdminPassword return the password of the admin user.
+func (c Container) AdminPassword() string {
+	return c.options.AdminPassword
+}
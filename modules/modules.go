@@ -0,0 +1,3 @@
+// Package modules gather preconfigured containers for common services, one sub-package per service (eg: modules/postgres, modules/vault).
+// Every module start its container through core.Start, waiting for the service to be ready, and return it with a function stopping and removing it.
+package modules
@@ -0,0 +1,147 @@
+// Package mysql start a MySQL or MariaDB server for integration tests.
+// To create the container, see the New() function.
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/normegil/docker/modules/internal/initscripts"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "mysql:8.4"
+const defaultRootPassword = "root"
+const defaultDatabase = "test"
+const defaultStartupTimeout = time.Minute
+
+// initDirectory is the directory from which the image run the initialisation scripts, on the first startup.
+const initDirectory = "/docker-entrypoint-initdb.d"
+
+// configPath is the path of the custom configuration, in a directory included by the configuration of the MySQL and MariaDB images.
+const configPath = "/etc/mysql/conf.d/zz-custom.cnf"
+
+var mysqlPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         3306,
+	ExternalInterval: "[13306;14306]",
+}
+
+// Options to configure the MySQL server.
+type Options struct {
+	// Image of the server. Default to mysql:8.4. MariaDB images (eg: mariadb:11) are supported.
+	Image string
+	// RootPassword is the password of the root user. Default to root.
+	RootPassword string
+	// Database to create. Default to test.
+	Database string
+	// User and Password, if specified, are a user created with all privileges on Database. DSN() then use it instead of root.
+	User     string
+	Password string
+	// Config, if specified, is the host path of a configuration file (my.cnf), overriding the server defaults (eg: sql_mode, character sets).
+	Config string
+	// InitScripts are host paths of scripts (.sql, .sql.gz or .sh) run, in order, when the database is created (eg: schema, seed data). SQL scripts are run in Database.
+	InitScripts []string
+	// InMemory store the data on a tmpfs, which dramatically speed up tests writing a lot. The data is lost when the container is removed anyway.
+	InMemory bool
+	// StartupTimeout is the maximum time to wait for the server to be ready, including the init scripts. Default to 1 minute.
+	StartupTimeout time.Duration
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running MySQL server.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start a MySQL server, waiting for it to answer queries over TCP (the server started by the image to run the init scripts only listen on its socket). The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+	files, err := initscripts.Files(initDirectory, options.InitScripts, ".sql", ".sql.gz", ".sh")
+	if nil != err {
+		return nil, nil, err
+	}
+	if "" != options.Config {
+		content, err := ioutil.ReadFile(options.Config)
+		if nil != err {
+			return nil, nil, errors.Wrapf(err, "Reading configuration %s", options.Config)
+		}
+		// The server ignore world-writable configuration files
+		files = append(files, docker.File{ContainerPath: configPath, Content: content, Mode: 0644})
+	}
+
+	env := map[string]string{
+		"MYSQL_ROOT_PASSWORD": options.RootPassword,
+		"MYSQL_DATABASE":      options.Database,
+	}
+	if "" != options.User {
+		env["MYSQL_USER"] = options.User
+		env["MYSQL_PASSWORD"] = options.Password
+	}
+	containerOptions := core.Options{
+		Name:                 "mysql",
+		Image:                options.Image,
+		Ports:                []docker.PortBinding{mysqlPort},
+		EnvironmentVariables: env,
+		Files:                files,
+		StartupTimeout:       options.StartupTimeout,
+		WaitStrategy: docker.ForAll(
+			docker.ForListeningPort(mysqlPort),
+			docker.ForExec(query(options.RootPassword, options.Database, "SELECT 1")...),
+		),
+		Logger: options.Logger,
+	}
+	if options.InMemory {
+		containerOptions = docker.TmpfsDataDirectory(containerOptions)
+	}
+	started, err := core.Start(containerOptions)
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: started.ContainerInfo, options: options}, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.RootPassword {
+		options.RootPassword = defaultRootPassword
+	}
+	if "" == options.Database {
+		options.Database = defaultDatabase
+	}
+	if 0 == options.StartupTimeout {
+		options.StartupTimeout = defaultStartupTimeout
+	}
+	return options
+}
+
+// query return the command executing the given query as root over TCP, inside the container.
+// Recent MariaDB images only ship the mariadb client, MySQL images the mysql one.
+func query(rootPassword string, database string, statement string) []string {
+	return []string{"sh", "-c", `if command -v mariadb >/dev/null; then CLIENT=mariadb; else CLIENT=mysql; fi; MYSQL_PWD="$0" $CLIENT --protocol=TCP -h 127.0.0.1 -u root -e "$2" "$1"`, rootPassword, database, statement}
+}
+
+// Endpoint return the "host:port" address of the server.
+func (c Container) Endpoint() string {
+	return c.ContainerInfo.Endpoint(mysqlPort)
+}
+
+// DSN return a data source name (user:password@tcp(host:port)/database?parseTime=true), usable with github.com/go-sql-driver/mysql. The user is Options.User if specified, root otherwise.
+func (c Container) DSN() string {
+	user, password := "root", c.options.RootPassword
+	if "" != c.options.User {
+		user, password = c.options.User, c.options.Password
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", user, password, c.Endpoint(), url.PathEscape(c.options.Database))
+}
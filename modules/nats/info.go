@@ -0,0 +1,83 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+)
+
+const infoTimeout = 2 * time.Second
+
+// ForInfo wait for the server on the given port to greet the clients (INFO line), and to accept a connection with the given credentials (CONNECT, answered to a PING).
+// If jetStream is true, the server must also announce JetStream as enabled.
+func ForInfo(binding docker.PortBinding, user string, password string, jetStream bool) docker.WaitStrategy {
+	return docker.WaitStrategyFunc(func(ctx context.Context, target docker.WaitTarget) error {
+		hostport := target.Endpoint(binding)
+		var lastErr error
+		for {
+			if lastErr = handshake(ctx, hostport, user, password, jetStream); nil == lastErr {
+				return nil
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("Could not connect to NATS server %s: %v", hostport, lastErr)
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	})
+}
+
+// handshake run the beginning of the client protocol: read the INFO line, send CONNECT then PING, and expect a PONG (an -ERR line being sent instead if the connection is refused).
+func handshake(ctx context.Context, hostport string, user string, password string, jetStream bool) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostport)
+	if nil != err {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(infoTimeout))
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if nil != err {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("Unexpected greeting: %s", strings.TrimSpace(line))
+	}
+	var info struct {
+		JetStream bool `json:"jetstream"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); nil != err {
+		return fmt.Errorf("Parsing INFO line: %v", err)
+	}
+	if jetStream && !info.JetStream {
+		return fmt.Errorf("JetStream is not enabled yet")
+	}
+
+	connect, err := json.Marshal(struct {
+		Verbose  bool   `json:"verbose"`
+		User     string `json:"user,omitempty"`
+		Password string `json:"pass,omitempty"`
+	}{User: user, Password: password})
+	if nil != err {
+		return err
+	}
+	if _, err := conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); nil != err {
+		return err
+	}
+	line, err = reader.ReadString('\n')
+	if nil != err {
+		return err
+	}
+	if "PONG" != strings.TrimSpace(line) {
+		return fmt.Errorf("Unexpected answer to PING: %s", strings.TrimSpace(line))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Package nats start a NATS server for integration tests, optionally with JetStream.
+// To create the container, see the New() function.
+package nats
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+)
+
+const defaultImage = "nats:2.10"
+const defaultServerName = "test"
+const startupTimeout = 30 * time.Second
+
+// configPath is the path of the generated configuration, in the container.
+const configPath = "/etc/nats/test.conf"
+
+var clientPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         4222,
+	ExternalInterval: "[14222;15222]",
+}
+
+// Options to configure the NATS server.
+type Options struct {
+	// Image of the NATS server. Default to nats:2.10.
+	Image string
+	// JetStream enable the persistence layer of NATS (streams, key-value and object stores). Its data is stored in the container.
+	JetStream bool
+	// User and Password, if specified, are required from the clients. URL() include them.
+	User     string
+	Password string
+	// ServerName is the name of the server. Default to test.
+	ServerName string
+	// Cluster, if specified, is the name of a cluster of which the server is the only member, for clients or features depending on the server being clustered. Its route port is not published.
+	Cluster string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running NATS server.
+type Container struct {
+	docker.ContainerInfo
+	options Options
+}
+
+// New start a NATS server, waiting for it to accept a client connection (See ForInfo). The returned function stop and remove the container.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+
+	started, err := core.Start(core.Options{
+		Name:  "nats",
+		Image: options.Image,
+		Cmd:   []string{"--config", configPath},
+		Ports: []docker.PortBinding{clientPort},
+		Files: []docker.File{{
+			ContainerPath: configPath,
+			Content:       []byte(config(options)),
+			Mode:          0644,
+		}},
+		StartupTimeout: startupTimeout,
+		WaitStrategy:   ForInfo(clientPort, options.User, options.Password, options.JetStream),
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: started.ContainerInfo, options: options}, func() error {
+		return started.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.ServerName {
+		options.ServerName = defaultServerName
+	}
+	return options
+}
+
+// config return the configuration file of the server.
+func config(options Options) string {
+	lines := []string{
+		"listen: 0.0.0.0:4222",
+		"http: 0.0.0.0:8222",
+		"server_name: " + strconv.Quote(options.ServerName),
+	}
+	if options.JetStream {
+		lines = append(lines, "jetstream {", `  store_dir: "/data/jetstream"`, "}")
+	}
+	if "" != options.User {
+		lines = append(lines, "authorization {", "  user: "+strconv.Quote(options.User), "  password: "+strconv.Quote(options.Password), "}")
+	}
+	if "" != options.Cluster {
+		lines = append(lines, "cluster {", "  name: "+strconv.Quote(options.Cluster), "  listen: 0.0.0.0:6222", "}")
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// URL return the URL of the server (eg: nats://127.0.0.1:14222), including the credentials if any.
+func (c Container) URL() string {
+	u := url.URL{Scheme: "nats", Host: c.Endpoint(clientPort)}
+	if "" != c.options.User {
+		u.User = url.UserPassword(c.options.User, c.options.Password)
+	}
+	return u.String()
+}
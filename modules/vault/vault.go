@@ -0,0 +1,131 @@
+// Package vault start a HashiCorp Vault server in dev mode for integration tests: it is unsealed, store its data in memory, and its root token is known.
+// To create the container, see the New() function.
+package vault
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/normegil/docker/core"
+	"github.com/pkg/errors"
+)
+
+const defaultImage = "hashicorp/vault:1.17"
+const defaultRootToken = "root"
+const startupTimeout = 30 * time.Second
+
+// localAddress is the address of the server, from inside the container.
+const localAddress = "http://127.0.0.1:8200"
+
+var vaultPort = docker.PortBinding{
+	Protocol:         "tcp",
+	Internal:         8200,
+	ExternalInterval: "[18200;19200]",
+}
+
+// Options to configure the Vault server.
+type Options struct {
+	// Image of the Vault server. Default to hashicorp/vault:1.17.
+	Image string
+	// RootToken is the token of the root user. Default to root.
+	RootToken string
+	// Logger used during initialisation of the container.
+	Logger docker.Logger
+}
+
+// Container is a running Vault server.
+type Container struct {
+	docker.ContainerInfo
+	options   Options
+	container *docker.Container
+}
+
+// New start a Vault server in dev mode, waiting for it to be unsealed and active. The returned function stop and remove the container.
+// In dev mode, a KV version 2 secrets engine is mounted on secret/.
+func New(options Options) (*Container, func() error, error) {
+	options = withDefaults(options)
+
+	c, err := core.Start(core.Options{
+		Name:  "vault",
+		Image: options.Image,
+		Cmd:   []string{"server", "-dev"},
+		Ports: []docker.PortBinding{vaultPort},
+		EnvironmentVariables: map[string]string{
+			"VAULT_DEV_ROOT_TOKEN_ID":  options.RootToken,
+			"VAULT_DEV_LISTEN_ADDRESS": "0.0.0.0:8200",
+			// The dev server doesn't lock its memory
+			"SKIP_SETCAP": "true",
+		},
+		StartupTimeout: startupTimeout,
+		WaitStrategy:   docker.ForHTTP(vaultPort, "/v1/sys/health", nil),
+		Logger:         options.Logger,
+	})
+	if nil != err {
+		return nil, nil, err
+	}
+	return &Container{ContainerInfo: c.ContainerInfo, options: options, container: c}, func() error {
+		return c.Terminate(context.Background())
+	}, nil
+}
+
+func withDefaults(options Options) Options {
+	if "" == options.Image {
+		options.Image = defaultImage
+	}
+	if "" == options.RootToken {
+		options.RootToken = defaultRootToken
+	}
+	return options
+}
+
+// Address return the HTTP address of the server (eg: http://127.0.0.1:18200), to use as VAULT_ADDR.
+func (c Container) Address() string {
+	return "http://" + c.Endpoint(vaultPort)
+}
+
+// Token return the root token, to use as VAULT_TOKEN.
+func (c Container) Token() string {
+	return c.options.RootToken
+}
+
+// MountSecretsEngine enable a secrets engine of the given type (eg: kv-v2, transit, pki) on the given path, with optional arguments of "vault secrets enable" (eg: -max-lease-ttl=1h).
+func (c Container) MountSecretsEngine(ctx context.Context, path string, engine string, arguments ...string) error {
+	command := append([]string{"secrets", "enable", "-path=" + path}, arguments...)
+	return c.Vault(ctx, append(command, engine)...)
+}
+
+// WriteSecret write the given data in the KV secrets engine (version 1 or 2), at the given path (eg: secret/database).
+func (c Container) WriteSecret(ctx context.Context, path string, data map[string]string) error {
+	return c.Vault(ctx, append([]string{"kv", "put", path}, pairs(data)...)...)
+}
+
+// Write write the given data at the given path, for secrets engines other than KV (eg: transit/keys/orders, database/roles/readonly).
+func (c Container) Write(ctx context.Context, path string, data map[string]string) error {
+	return c.Vault(ctx, append([]string{"write", path}, pairs(data)...)...)
+}
+
+// Vault run the vault CLI inside the container, authenticated with the root token (eg: Vault(ctx, "policy", "write", ...)).
+func (c Container) Vault(ctx context.Context, arguments ...string) error {
+	cmd := append([]string{"env", "VAULT_ADDR=" + localAddress, "VAULT_TOKEN=" + c.options.RootToken, "vault"}, arguments...)
+	code, output, err := c.container.Exec(ctx, cmd...)
+	if nil != err {
+		return errors.Wrapf(err, "Running vault %s", strings.Join(arguments, " "))
+	}
+	if 0 != code {
+		return errors.Errorf("Running vault %s failed (Exit code: %d): %s", strings.Join(arguments, " "), code, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// pairs return the data as sorted key=value arguments.
+func pairs(data map[string]string) []string {
+	arguments := make([]string, 0, len(data))
+	for key, value := range data {
+		arguments = append(arguments, key+"="+value)
+	}
+	sort.Strings(arguments)
+	return arguments
+}
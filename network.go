@@ -0,0 +1,100 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// networkingConfig return the configuration attaching the container to Options.Network, with its aliases. Nil is returned if no network is specified.
+func networkingConfig(options Options) *network.NetworkingConfig {
+	if "" == options.Network {
+		return nil
+	}
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			options.Network: {Aliases: options.NetworkAliases},
+		},
+	}
+}
+
+// createNetwork create a bridge network, labeled like the containers of this package (See CleanupOrphans), and return its ID.
+func createNetwork(ctx context.Context, client *docker.Client, name string) (string, error) {
+	created, err := client.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Labels: map[string]string{
+			LabelSession: SessionID(),
+			LabelCreator: creator,
+			LabelCreated: time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if nil != err {
+		return "", errors.Wrapf(err, "Creating network %s", name)
+	}
+	return created.ID, nil
+}
+
+// DisconnectNetwork disconnect the container from the given network (eg: Options.Network, or "bridge" for the default network), simulating a network partition with the other containers of the network. Open connections are broken.
+// See ReconnectNetwork to end the partition.
+func (c *Container) DisconnectNetwork(ctx context.Context, name string) error {
+	inspected, err := c.client.ContainerInspect(ctx, c.Identifier)
+	if nil != err {
+		return errors.Wrapf(err, "Inspecting %s", c.Name)
+	}
+	var settings *network.EndpointSettings
+	if nil != inspected.NetworkSettings {
+		settings = inspected.NetworkSettings.Networks[name]
+	}
+	if nil == settings {
+		return errors.Errorf("Container %s is not connected to network %s", c.Name, name)
+	}
+	c.logger.Printf("Disconnecting container %s from network %s", c.Name, name)
+	if err := c.client.NetworkDisconnect(ctx, name, c.Identifier, true); nil != err {
+		return errors.Wrapf(err, "Disconnecting %s from network %s", c.Name, name)
+	}
+	if nil == c.disconnected {
+		c.disconnected = make(map[string]*network.EndpointSettings)
+	}
+	c.disconnected[name] = settings
+	return nil
+}
+
+// ReconnectNetwork reconnect the container to a network it was disconnected from (See DisconnectNetwork), with the same aliases, so that the other containers reach it again under the same names.
+func (c *Container) ReconnectNetwork(ctx context.Context, name string) error {
+	config := &network.EndpointSettings{}
+	if settings, ok := c.disconnected[name]; ok {
+		config.Aliases = settings.Aliases
+		config.Links = settings.Links
+		config.IPAMConfig = settings.IPAMConfig
+	} else if name == c.options.Network {
+		config.Aliases = c.options.NetworkAliases
+	}
+	c.logger.Printf("Reconnecting container %s to network %s", c.Name, name)
+	if err := c.client.NetworkConnect(ctx, name, c.Identifier, config); nil != err {
+		return errors.Wrapf(err, "Reconnecting %s to network %s", c.Name, name)
+	}
+	delete(c.disconnected, name)
+	return nil
+}
+
+// NetworkAddress return the address of the container on the given network (eg: Options.Network, or "bridge" for the default network), on which the other containers of this network reach it on its internal ports.
+func (c *Container) NetworkAddress(ctx context.Context, name string) (net.IP, error) {
+	inspected, err := c.client.ContainerInspect(ctx, c.Identifier)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting %s", c.Name)
+	}
+	if nil == inspected.NetworkSettings || nil == inspected.NetworkSettings.Networks[name] {
+		return nil, errors.Errorf("Container %s is not connected to network %s", c.Name, name)
+	}
+	address := net.ParseIP(inspected.NetworkSettings.Networks[name].IPAddress)
+	if nil == address {
+		return nil, errors.Errorf("Container %s has no address on network %s", c.Name, name)
+	}
+	return address, nil
+}
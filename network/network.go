@@ -0,0 +1,26 @@
+// Package network gather how the containers are reached: the ports published on the host, and the groups of containers sharing a dedicated network.
+// It is a facade over the root package (docker.PortBinding, docker.Group, ...), which stay available: both can be used interchangeably.
+package network
+
+import (
+	"github.com/normegil/docker"
+)
+
+// Protocols supported by PortBinding.
+const (
+	TCP  = docker.ProtocolTCP
+	UDP  = docker.ProtocolUDP
+	SCTP = docker.ProtocolSCTP
+)
+
+// PortBinding is a port of the container published on the host (See docker.Options.Ports).
+type PortBinding = docker.PortBinding
+
+// Group declare several containers working together, started in dependency order on a network dedicated to the group.
+type Group = docker.Group
+
+// Member is a container of a Group.
+type Member = docker.GroupMember
+
+// Environment is a started Group.
+type Environment = docker.Environment
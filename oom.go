@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// errOOMKilled is returned while waiting for a container killed for exceeding its memory limit, which is not worth waiting for.
+var errOOMKilled = errors.New("Container killed: out of memory")
+
+// notOOMKilled check if waiting for the container is still worth it (See Backoff.Retry).
+func notOOMKilled(err error) bool {
+	return errOOMKilled != err
+}
+
+// followMemoryPeak follow, in background, the memory used by a container with a memory limit (See Resources.Memory), to report its peak usage if it is killed for exceeding the limit (See ErrOOMKilled).
+// The returned function stop following the container, and return the peak usage in bytes (0 if the container has no memory limit, or its usage could not be measured).
+func followMemoryPeak(client *docker.Client, containerID string, options Options) func() uint64 {
+	if 0 == options.Resources.Memory {
+		return func() uint64 { return 0 }
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var peak uint64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		response, err := client.ContainerStats(ctx, containerID, true)
+		if nil != err {
+			return
+		}
+		defer response.Body.Close()
+		decoder := json.NewDecoder(response.Body)
+		for {
+			var raw rawStats
+			if err := decoder.Decode(&raw); nil != err {
+				return
+			}
+			// The cache count toward the limit: the raw usage is kept
+			for _, usage := range []uint64{raw.MemoryStats.Usage, raw.MemoryStats.MaxUsage} {
+				if usage > peak {
+					peak = usage
+				}
+			}
+		}
+	}()
+	return func() uint64 {
+		cancel()
+		<-done
+		return peak
+	}
+}
@@ -0,0 +1,30 @@
+module github.com/normegil/docker/oteltrace
+
+go 1.23.0
+
+require (
+	github.com/normegil/docker v0.1.0
+	go.opentelemetry.io/otel v1.38.0
+	go.opentelemetry.io/otel/trace v1.38.0
+)
+
+require (
+	github.com/Microsoft/go-winio v0.4.5 // indirect
+	github.com/docker/distribution v2.6.2+incompatible // indirect
+	github.com/docker/docker v1.13.1 // indirect
+	github.com/docker/go-connections v0.4.0 // indirect
+	github.com/docker/go-units v0.3.2 // indirect
+	github.com/go-logr/logr v1.4.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/google/uuid v1.1.1 // indirect
+	github.com/normegil/connectionutils v0.0.0-20181220171258-4a33da0f3393 // indirect
+	github.com/normegil/interval v0.0.0-20181220165130-6c2976dd2323 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	go.opentelemetry.io/auto/sdk v1.2.0 // indirect
+	go.opentelemetry.io/otel/metric v1.38.0 // indirect
+	golang.org/x/net v0.0.0-20171024115130-4b14673ba32b // indirect
+	golang.org/x/sys v0.0.0-20171026072640-3e3646d2c706 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
+)
+
+replace github.com/normegil/docker => ../
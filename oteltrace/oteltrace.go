@@ -0,0 +1,58 @@
+// Package oteltrace record the startup of the containers as OpenTelemetry spans (See docker.Options.Tracer).
+// It is a separate module, so that the root package does not depend on OpenTelemetry.
+package oteltrace
+
+import (
+	"context"
+
+	"github.com/normegil/docker"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/normegil/docker"
+
+// New return a docker.Tracer creating the spans with the given provider, or with the global provider (See otel.SetTracerProvider) if provider is nil.
+// The spans are children of the span of parent, if any (eg: the span of the current test or of TestMain).
+func New(parent context.Context, provider trace.TracerProvider) docker.Tracer {
+	if nil == provider {
+		provider = otel.GetTracerProvider()
+	}
+	if nil == parent {
+		parent = context.Background()
+	}
+	return tracer{parent: parent, tracer: provider.Tracer(instrumentationName)}
+}
+
+type tracer struct {
+	parent context.Context
+	tracer trace.Tracer
+}
+
+func (t tracer) Start(ctx context.Context, name string) (context.Context, docker.Span) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+		ctx = t.parent
+	}
+	ctx, span := t.tracer.Start(ctx, name)
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttributes(attributes map[string]string) {
+	for key, value := range attributes {
+		s.span.SetAttributes(attribute.String(key, value))
+	}
+}
+
+func (s otelSpan) End(err error) {
+	if nil != err {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
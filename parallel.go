@@ -0,0 +1,66 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// parallelStarts is the maximum number of containers started at the same time by NewAll. Startups are mostly waiting on the daemon and on readiness checks, but starting too many heavyweight containers at once slow them all down.
+const parallelStarts = 4
+
+// NewAll start the containers described by the given options concurrently, and return them in the same order once they are all ready. Containers which don't depend on each other (eg: a database, a cache and a message broker) are then ready after the slowest startup, instead of the sum of their startups.
+// If a container cannot be started, the others are terminated and the returned error list every failure. Use Group for containers which must be started in a specific order.
+func NewAll(ctx context.Context, options ...Options) ([]*Container, error) {
+	containers := make([]*Container, len(options))
+	failures := make([]string, 0)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, parallelStarts)
+	for i, containerOptions := range options {
+		wg.Add(1)
+		go func(i int, containerOptions Options) {
+			defer wg.Done()
+			name := containerOptions.Name
+			if "" == name {
+				name = "#" + strconv.Itoa(i) + " (" + containerOptions.Image + ")"
+			}
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				mutex.Lock()
+				failures = append(failures, name+": "+ctx.Err().Error())
+				mutex.Unlock()
+				return
+			}
+			c, err := Start(containerOptions)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if nil != err {
+				failures = append(failures, name+": "+err.Error())
+				return
+			}
+			containers[i] = c
+		}(i, containerOptions)
+	}
+	wg.Wait()
+
+	if 0 != len(failures) {
+		sort.Strings(failures)
+		for _, c := range containers {
+			if nil == c {
+				continue
+			}
+			if err := c.Terminate(context.Background()); nil != err {
+				c.logger.Errorf("Could not terminate %s: %+v", c.Name, err)
+			}
+		}
+		return nil, errors.New("Starting containers: " + strings.Join(failures, "; "))
+	}
+	return containers, nil
+}
@@ -0,0 +1,54 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// pullPlatform pull the image of the options for their platform. The docker client used by this package predates multi-platform pulls, and the daemon API is called directly.
+func pullPlatform(ctx context.Context, options Options, auth string) (io.ReadCloser, error) {
+	reference := options.Image
+	platform := options.Platform
+	host, err := backendHost(options)
+	if nil != err {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("fromImage", repositoryOf(reference))
+	if index := strings.Index(reference, "@"); -1 != index {
+		query.Set("fromImage", reference)
+	} else if tag := strings.TrimPrefix(reference, repositoryOf(reference)); "" != tag {
+		query.Set("tag", strings.TrimPrefix(tag, ":"))
+	} else {
+		query.Set("tag", "latest")
+	}
+	query.Set("platform", platform)
+	headers := make(map[string]string)
+	if "" != auth {
+		headers["X-Registry-Auth"] = auth
+	}
+	response, err := daemonRequest(ctx, host, http.MethodPost, "/images/create", query, headers, nil)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Pulling image %s for platform %s", reference, platform)
+	}
+	return response.Body, nil
+}
+
+// platformMatch check if the local image was built for the given platform (os/architecture[/variant]).
+func platformMatch(ctx context.Context, client *docker.Client, reference string, platform string) (bool, error) {
+	image, _, err := client.ImageInspectWithRaw(ctx, reference)
+	if nil != err {
+		return false, errors.Wrapf(err, "Inspecting image %s", reference)
+	}
+	parts := strings.Split(platform, "/")
+	if image.Os != parts[0] {
+		return false, nil
+	}
+	return 1 == len(parts) || image.Architecture == parts[1], nil
+}
@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	docker "github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/normegil/connectionutils"
+	"github.com/normegil/interval"
+	"github.com/pkg/errors"
+)
+
+// protocol return the normalized protocol of the binding.
+func (b PortBinding) protocol() string {
+	return strings.ToLower(b.Protocol)
+}
+
+// dockerPort return the docker representation of an internal port of the binding.
+func (b PortBinding) dockerPort(internal int) nat.Port {
+	return nat.Port(strconv.Itoa(internal) + "/" + b.protocol())
+}
+
+// lastInternal return the last internal port published by the binding.
+func (b PortBinding) lastInternal() int {
+	if b.InternalEnd > b.Internal {
+		return b.InternalEnd
+	}
+	return b.Internal
+}
+
+// size return the number of ports published by the binding.
+func (b PortBinding) size() int {
+	return b.lastInternal() - b.Internal + 1
+}
+
+func toExposedPorts(ports []PortBinding) nat.PortSet {
+	exposed := make(map[nat.Port]struct{})
+	for _, binding := range ports {
+		for internal := binding.Internal; internal <= binding.lastInternal(); internal++ {
+			exposed[binding.dockerPort(internal)] = struct{}{}
+		}
+	}
+	return nat.PortSet(exposed)
+}
+
+func selectPorts(addresses []net.IP, possiblePorts []PortBinding) (map[PortBinding]int, error) {
+	used := make([]int, 0)
+	toReturn := make(map[PortBinding]int)
+	for _, binding := range possiblePorts {
+		interval, err := interval.ParseIntervalInteger(binding.ExternalInterval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Parsing %s", binding.ExternalInterval)
+		}
+		selected := selectPortRange(bindingAddresses(binding, addresses), binding.protocol(), *interval, binding.size(), used)
+		if 0 == selected {
+			return nil, fmt.Errorf("No available range of %d port(s) in %s", binding.size(), binding.ExternalInterval)
+		}
+		for port := selected; port < selected+binding.size(); port++ {
+			used = append(used, port)
+		}
+		toReturn[binding] = selected
+	}
+	return toReturn, nil
+}
+
+// selectPortRange return the first port of a contiguous block of ports, of the given size, in the interval and available on every given addresses. Return 0 if no such block is available.
+func selectPortRange(addresses []net.IP, protocol string, possibilities interval.IntervalInteger, size int, excluding []int) int {
+	for first := possibilities.LowestNumberIncluded(); first+size-1 <= possibilities.HighestNumberIncluded(); first++ {
+		available := true
+		for port := first; port < first+size && available; port++ {
+			available = portAvailable(addresses, protocol, port, excluding)
+		}
+		if available {
+			return first
+		}
+	}
+	return 0
+}
+
+// portAvailable check if the port is available on every given addresses.
+// SCTP ports cannot be checked without system specific code, and are always considered available.
+func portAvailable(addresses []net.IP, protocol string, port int, excluding []int) bool {
+	if contains(excluding, port) {
+		return false
+	}
+	for _, address := range addresses {
+		switch protocol {
+		case ProtocolTCP:
+			if !connectionutils.TCPPortAvalaible(&net.TCPAddr{IP: address, Port: port}) {
+				return false
+			}
+		case ProtocolUDP:
+			if !udpPortAvailable(&net.UDPAddr{IP: address, Port: port}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func udpPortAvailable(addr *net.UDPAddr) bool {
+	conn, err := net.ListenUDP("udp", addr)
+	if nil != err {
+		return false
+	}
+	defer conn.Close()
+	return true
+}
+
+func contains(slice []int, value int) bool {
+	for _, sliceValue := range slice {
+		if value == sliceValue {
+			return true
+		}
+	}
+	return false
+}
+
+func toDockerPortBindings(addresses []net.IP, ports map[PortBinding]int) map[nat.Port][]nat.PortBinding {
+	toReturn := make(map[nat.Port][]nat.PortBinding)
+	for binding, selectedPort := range ports {
+		for offset := 0; offset < binding.size(); offset++ {
+			dockerPort := binding.dockerPort(binding.Internal + offset)
+			for _, address := range bindingAddresses(binding, addresses) {
+				hostIP := ""
+				if nil != address {
+					hostIP = address.String()
+				}
+				toReturn[dockerPort] = append(toReturn[dockerPort], nat.PortBinding{
+					HostIP:   hostIP,
+					HostPort: strconv.Itoa(selectedPort + offset),
+				})
+			}
+		}
+	}
+	return toReturn
+}
+
+// addPublishedPorts add to ports the ports published by the daemon, which were not explicitly requested (See Options.PublishAllPorts).
+func addPublishedPorts(client *docker.Client, containerID string, ports map[PortBinding]int) error {
+	inspected, err := client.ContainerInspect(context.Background(), containerID)
+	if nil != err {
+		return err
+	}
+	if nil == inspected.NetworkSettings {
+		return nil
+	}
+	for port, bindings := range inspected.NetworkSettings.Ports {
+		if 0 == len(bindings) || alreadyBound(ports, port) {
+			continue
+		}
+		hostPort, err := strconv.Atoi(bindings[0].HostPort)
+		if nil != err {
+			return errors.Wrapf(err, "Parsing host port of %s", port)
+		}
+		ports[PortBinding{Protocol: port.Proto(), Internal: port.Int()}] = hostPort
+	}
+	return nil
+}
+
+func alreadyBound(ports map[PortBinding]int, port nat.Port) bool {
+	for binding := range ports {
+		if binding.protocol() == port.Proto() && binding.Internal <= port.Int() && port.Int() <= binding.lastInternal() {
+			return true
+		}
+	}
+	return false
+}
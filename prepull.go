@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PrePull pull the given images in parallel, if they are not available locally. It is intended to be called once from TestMain, so that tests don't each pay (and time out on) the pull of their images.
+func PrePull(ctx context.Context, images ...string) error {
+	return PrePullWith(ctx, Options{}, images...)
+}
+
+// PrePullWith pull the given images in parallel, using the pull related settings of the options (Backend, Client, Logger, LogLevel, PullPolicy, PullProgress, RegistryAuth, Platform, Retries). Image is ignored.
+// The Logger receive a message each time an image is ready, with the number of images still being pulled.
+func PrePullWith(ctx context.Context, options Options, images ...string) error {
+	l := newLogger(options)
+	loaded, err := LoadConfig()
+	if nil != err {
+		return err
+	}
+	client, err := clientOf(options, l)
+	if nil != err {
+		return withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+
+	start := time.Now()
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	ready := 0
+	failures := make([]string, 0)
+	for _, image := range images {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			imageOptions := options
+			imageOptions.Image = image
+			err := pullImage(ctx, client, withConfig(imageOptions, loaded))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if nil != err {
+				failures = append(failures, image+": "+err.Error())
+				l.Warnf("Pre-pulling %s failed: %+v", image, err)
+				return
+			}
+			ready++
+			l.with(Fields{"duration": time.Since(start)}).Printf("Image ready: %s (%d/%d)", image, ready, len(images))
+		}(image)
+	}
+	wg.Wait()
+
+	if 0 != len(failures) {
+		return withKind(ErrImagePull, errors.New("Pre-pulling images: "+strings.Join(failures, "; ")))
+	}
+	return nil
+}
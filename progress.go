@@ -0,0 +1,266 @@
+package docker
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultProgressInterval = 5 * time.Second
+
+// Progress aggregate the startup state of several containers started together (See Options.Progress), and periodically report it while some of them are not ready.
+// Long environment boots in CI are then not silent: reports show how many containers are ready, and on which condition the others are waiting.
+type Progress struct {
+	// Interval between two reports. Default to 5 seconds.
+	Interval time.Duration
+	// Report is called with the current state of the containers. Default to logging the state through Logger.
+	Report func(report ProgressReport)
+	// Logger used by the default Report function.
+	Logger Logger
+
+	mutex      sync.Mutex
+	containers []*containerProgress
+	stop       chan struct{}
+}
+
+// ProgressReport is the state of the containers tracked by a Progress.
+type ProgressReport struct {
+	// Ready is the number of ready containers.
+	Ready int
+	// Total is the number of tracked containers.
+	Total int
+	// Waiting describe the containers which are not ready yet.
+	Waiting []WaitingContainer
+}
+
+// WaitingContainer describe a container which is not ready yet.
+type WaitingContainer struct {
+	// Name of the container (Options.Name).
+	Name string
+	// Condition is the condition the container is waiting on (eg: pulling image, could not reach 127.0.0.1:5432).
+	Condition string
+	// Elapsed is the time since the container startup began.
+	Elapsed time.Duration
+}
+
+// String return a human readable summary of the report.
+func (r ProgressReport) String() string {
+	waiting := make([]string, 0, len(r.Waiting))
+	for _, container := range r.Waiting {
+		waiting = append(waiting, container.Name+" ("+container.Elapsed.Round(time.Second).String()+"): "+container.Condition)
+	}
+	summary := "Ready: " + strconv.Itoa(r.Ready) + "/" + strconv.Itoa(r.Total)
+	if 0 != len(waiting) {
+		summary += " - Waiting for " + strings.Join(waiting, ", ")
+	}
+	return summary
+}
+
+type containerProgress struct {
+	progress  *Progress
+	name      string
+	start     time.Time
+	condition string
+	done      bool
+}
+
+// track start tracking a new container. A nil Progress return a nil tracker, whose methods do nothing.
+func (p *Progress) track(name string) *containerProgress {
+	if nil == p {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	tracked := &containerProgress{progress: p, name: name, start: time.Now(), condition: "initializing"}
+	p.containers = append(p.containers, tracked)
+	if nil == p.stop {
+		p.stop = make(chan struct{})
+		go p.reportPeriodically(p.stop)
+	}
+	return tracked
+}
+
+func (p *Progress) reportPeriodically(stop chan struct{}) {
+	interval := p.Interval
+	if 0 >= interval {
+		interval = defaultProgressInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.report(p.snapshot())
+		}
+	}
+}
+
+func (p *Progress) report(report ProgressReport) {
+	if nil != p.Report {
+		p.Report(report)
+		return
+	}
+	if nil != p.Logger {
+		p.Logger.Printf("Containers startup: %s", report)
+	}
+}
+
+func (p *Progress) snapshot() ProgressReport {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	report := ProgressReport{Total: len(p.containers)}
+	for _, tracked := range p.containers {
+		if tracked.done {
+			report.Ready++
+			continue
+		}
+		report.Waiting = append(report.Waiting, WaitingContainer{
+			Name:      tracked.name,
+			Condition: tracked.condition,
+			Elapsed:   time.Since(tracked.start),
+		})
+	}
+	sort.Slice(report.Waiting, func(i, j int) bool {
+		return report.Waiting[i].Name < report.Waiting[j].Name
+	})
+	return report
+}
+
+// waiting record the condition the container is currently waiting on.
+func (c *containerProgress) waiting(condition string) {
+	if nil == c {
+		return
+	}
+	c.progress.mutex.Lock()
+	defer c.progress.mutex.Unlock()
+	c.condition = condition
+}
+
+// finished mark the container as ready (or failed, in which case it is not tracked anymore). Reporting stop once every tracked container is finished.
+func (c *containerProgress) finished(ready bool) {
+	if nil == c {
+		return
+	}
+	p := c.progress
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	c.done = true
+	if !ready {
+		for i, tracked := range p.containers {
+			if tracked == c {
+				p.containers = append(p.containers[:i], p.containers[i+1:]...)
+				break
+			}
+		}
+	}
+	for _, tracked := range p.containers {
+		if !tracked.done {
+			return
+		}
+	}
+	if nil != p.stop {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// startupTracker follow the startup of a container: its progress (See Options.Progress), its spans (See Options.Tracer), its metrics (See Options.Metrics), and the durations of its phases (See Container.Timings). The methods of a nil tracker do nothing.
+type startupTracker struct {
+	progress *containerProgress
+	trace    *startupTrace
+	metrics  Metrics
+	name     string
+	image    string
+	start    time.Time
+	// durations of the phases, indexed by span name.
+	durations map[string]time.Duration
+	// failure is the last error of a phase.
+	failure error
+}
+
+// trackStartup start following the startup of the container created with the given options.
+func trackStartup(options Options) *startupTracker {
+	return &startupTracker{
+		progress:  options.Progress.track(options.Name),
+		trace:     startTrace(options),
+		metrics:   options.Metrics,
+		name:      options.Name,
+		image:     options.Image,
+		start:     time.Now(),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+// waiting record the condition the container is currently waiting on.
+func (t *startupTracker) waiting(condition string) {
+	if nil == t {
+		return
+	}
+	t.progress.waiting(condition)
+}
+
+// phase start the span of a startup phase (See SpanPull, ...), to end once the phase is over.
+func (t *startupTracker) phase(name string, attributes map[string]string) Span {
+	if nil == t {
+		return noSpan{}
+	}
+	return &phaseSpan{Span: t.trace.phase(name, attributes), tracker: t, name: name, start: time.Now()}
+}
+
+// phaseSpan record the duration and the error of a phase in the tracker.
+type phaseSpan struct {
+	Span
+	tracker *startupTracker
+	name    string
+	start   time.Time
+}
+
+func (s *phaseSpan) End(err error) {
+	// Phases retried after a failure (See Retries.Startup) accumulate their durations
+	s.tracker.durations[s.name] += time.Since(s.start)
+	if nil != err {
+		s.tracker.failure = err
+	}
+	s.Span.End(err)
+}
+
+// timings return the durations of the phases, and the time elapsed since the beginning of the startup.
+func (t *startupTracker) timings() StartupTimings {
+	if nil == t {
+		return StartupTimings{}
+	}
+	return StartupTimings{
+		Pull:   t.durations[SpanBuild] + t.durations[SpanPull],
+		Create: t.durations[SpanCreate],
+		Start:  t.durations[SpanStart],
+		Ready:  t.durations[SpanReadiness],
+		Total:  time.Since(t.start),
+	}
+}
+
+// finished stop following the startup. Info is the started container, or nil if the startup failed.
+func (t *startupTracker) finished(info *ContainerInfo) {
+	if nil == t {
+		return
+	}
+	t.progress.finished(nil != info)
+	t.trace.finish(info, t.failure)
+	if nil != info {
+		recordStartup(t.name, t.image, t.timings())
+	}
+	if nil != t.metrics {
+		t.metrics.ContainerStarted(t.image, time.Since(t.start), nil == info)
+	}
+}
+
+// notReady record that the container was not ready within its startup timeout.
+func (t *startupTracker) notReady() {
+	if nil == t || nil == t.metrics {
+		return
+	}
+	t.metrics.ReadinessFailed(t.image)
+}
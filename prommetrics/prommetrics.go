@@ -0,0 +1,88 @@
+// Package prommetrics export the measures of the container startups as Prometheus metrics (See docker.Options.Metrics).
+// It is a separate module, so that the root package does not depend on the Prometheus client.
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/normegil/docker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "docker"
+
+// New return a docker.Metrics registering, in the given registerer (prometheus.DefaultRegisterer if nil), the metrics:
+//   - docker_pulls_total and docker_startups_total: counters of pulls and startups, by image and result (success, failure).
+//   - docker_pull_duration_seconds and docker_startup_duration_seconds: histograms of the durations, by image (See docker.DurationBuckets).
+//   - docker_readiness_failures_total: counter of containers not ready within their startup timeout, by image.
+//
+// New must be called once per registerer: the Metrics should be shared by every container.
+func New(registerer prometheus.Registerer) (docker.Metrics, error) {
+	if nil == registerer {
+		registerer = prometheus.DefaultRegisterer
+	}
+	m := metrics{
+		pulls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "pulls_total",
+			Help:      "Number of image pulls, by image and result.",
+		}, []string{"image", "result"}),
+		pullDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "pull_duration_seconds",
+			Help:      "Duration of the image pulls.",
+			Buckets:   docker.DurationBuckets,
+		}, []string{"image"}),
+		startups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "startups_total",
+			Help:      "Number of container startups, by image and result.",
+		}, []string{"image", "result"}),
+		startupDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "startup_duration_seconds",
+			Help:      "Duration of the container startups, from the pull to the readiness.",
+			Buckets:   docker.DurationBuckets,
+		}, []string{"image"}),
+		readinessFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "readiness_failures_total",
+			Help:      "Number of containers not ready within their startup timeout, by image.",
+		}, []string{"image"}),
+	}
+	for _, collector := range []prometheus.Collector{m.pulls, m.pullDuration, m.startups, m.startupDuration, m.readinessFailures} {
+		if err := registerer.Register(collector); nil != err {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+type metrics struct {
+	pulls             *prometheus.CounterVec
+	pullDuration      *prometheus.HistogramVec
+	startups          *prometheus.CounterVec
+	startupDuration   *prometheus.HistogramVec
+	readinessFailures *prometheus.CounterVec
+}
+
+func (m metrics) ImagePulled(image string, duration time.Duration, failed bool) {
+	m.pulls.WithLabelValues(image, result(failed)).Inc()
+	m.pullDuration.WithLabelValues(image).Observe(duration.Seconds())
+}
+
+func (m metrics) ContainerStarted(image string, duration time.Duration, failed bool) {
+	m.startups.WithLabelValues(image, result(failed)).Inc()
+	m.startupDuration.WithLabelValues(image).Observe(duration.Seconds())
+}
+
+func (m metrics) ReadinessFailed(image string) {
+	m.readinessFailures.WithLabelValues(image).Inc()
+}
+
+func result(failed bool) string {
+	if failed {
+		return "failure"
+	}
+	return "success"
+}
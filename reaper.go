@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/pkg/errors"
+)
+
+const reaperImage = "testcontainers/ryuk:0.11.0"
+const defaultDockerSocket = "/var/run/docker.sock"
+
+var reaperPort = PortBinding{
+	Protocol:         ProtocolTCP,
+	Internal:         8080,
+	ExternalInterval: "[28080;29080]",
+}
+
+// CleanupOrphans remove the containers, networks and volumes created by this package, in other sessions (See SessionID), more than olderThan ago.
+// It allow to clean the resources leaked by previous test runs which crashed, or were killed, before removing them.
+func CleanupOrphans(ctx context.Context, olderThan time.Duration) error {
+	limit := time.Now().Add(-olderThan)
+	return removeCreated(ctx, "orphans", func(labels map[string]string) bool {
+		return orphan(labels, limit)
+	})
+}
+
+// removeCreated remove the containers, networks and volumes created by this package, whose labels match. What describe the removed resources in the returned error.
+func removeCreated(ctx context.Context, what string, match func(labels map[string]string) bool) error {
+	client, err := newClient(nil)
+	if nil != err {
+		return errors.Wrap(err, "Could not create docker client")
+	}
+	args := filters.NewArgs()
+	args.Add("label", LabelCreator+"="+creator)
+
+	containers, err := client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if nil != err {
+		return errors.Wrap(err, "Listing containers")
+	}
+	failures := make([]string, 0)
+	for _, container := range containers {
+		if !match(container.Labels) {
+			continue
+		}
+		if err := client.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); nil != err {
+			failures = append(failures, "container "+container.ID+": "+err.Error())
+		}
+	}
+
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{Filters: args})
+	if nil != err {
+		return errors.Wrap(err, "Listing networks")
+	}
+	for _, network := range networks {
+		if !match(network.Labels) {
+			continue
+		}
+		if err := client.NetworkRemove(ctx, network.ID); nil != err {
+			failures = append(failures, "network "+network.Name+": "+err.Error())
+		}
+	}
+
+	volumes, err := client.VolumeList(ctx, args)
+	if nil != err {
+		return errors.Wrap(err, "Listing volumes")
+	}
+	for _, volume := range volumes.Volumes {
+		if !match(volume.Labels) {
+			continue
+		}
+		if err := client.VolumeRemove(ctx, volume.Name, true); nil != err {
+			failures = append(failures, "volume "+volume.Name+": "+err.Error())
+		}
+	}
+
+	if 0 != len(failures) {
+		return errors.New("Could not remove " + what + ": " + strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// orphan check if the resource, with the given labels, was created by another session before limit. Reusable containers (See Options.Reuse) are never orphans.
+func orphan(labels map[string]string, limit time.Time) bool {
+	if SessionID() == labels[LabelSession] || "" != labels[LabelReuse] {
+		return false
+	}
+	created, err := time.Parse(time.RFC3339, labels[LabelCreated])
+	if nil != err {
+		return false
+	}
+	return created.Before(limit)
+}
+
+// Reaper is a sidecar container (testcontainers/ryuk) removing every resource of the current session once the test process is gone, even if it was killed (SIGKILL, OOM, CI timeout, ...).
+// The reaper is notified of the end of the session when its connection with the test process is closed.
+type Reaper struct {
+	container *Container
+	conn      net.Conn
+}
+
+// StartReaper start the reaper sidecar for the current session. Close should be called at the end of the tests, but the reaper also do its job if the process die before.
+func StartReaper(logger Logger) (*Reaper, error) {
+	c, err := Start(Options{
+		Name:       "reaper",
+		Image:      reaperImage,
+		Ports:      []PortBinding{reaperPort},
+		Binds:      []Bind{{HostPath: dockerSocket(), ContainerPath: "/var/run/docker.sock"}},
+		AutoRemove: true,
+		Privileged: true,
+		Logger:     logger,
+	})
+	if nil != err {
+		return nil, errors.Wrap(err, "Starting reaper")
+	}
+
+	conn, err := net.DialTimeout("tcp", c.Endpoint(reaperPort), maxWaitTime)
+	if nil != err {
+		c.Terminate(context.Background())
+		return nil, errors.Wrap(err, "Connecting to reaper")
+	}
+	if _, err := conn.Write([]byte("label=" + LabelSession + "=" + SessionID() + "\n")); nil != err {
+		conn.Close()
+		c.Terminate(context.Background())
+		return nil, errors.Wrap(err, "Registering session in reaper")
+	}
+	conn.SetReadDeadline(time.Now().Add(maxWaitTime))
+	ack, err := bufio.NewReader(conn).ReadString('\n')
+	if nil != err || "ACK" != strings.TrimSpace(ack) {
+		conn.Close()
+		c.Terminate(context.Background())
+		return nil, errors.Errorf("Reaper did not acknowledge the session: %q (%v)", ack, err)
+	}
+	conn.SetReadDeadline(time.Time{})
+	return &Reaper{container: c, conn: conn}, nil
+}
+
+// Close disconnect from the reaper, which then remove every resource of the session (including itself).
+func (r *Reaper) Close() error {
+	return r.conn.Close()
+}
+
+// dockerSocket return the path of the API socket of the detected backend (See DetectBackend) on the host running the containers.
+// Sockets in the home directory (Colima, Rancher Desktop, Docker Desktop) are forwarded from a VM, where the daemon listen on the default socket.
+func dockerSocket() string {
+	host, err := DetectBackend().Host()
+	if nil != err || !strings.HasPrefix(host, "unix://") {
+		return defaultDockerSocket
+	}
+	socket := strings.TrimPrefix(host, "unix://")
+	if home, err := os.UserHomeDir(); nil == err && strings.HasPrefix(socket, home+string(os.PathSeparator)) {
+		return defaultDockerSocket
+	}
+	return socket
+}
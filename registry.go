@@ -0,0 +1,171 @@
+package docker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// dockerHubRegistry is the key used for Docker Hub in the docker configuration file.
+const dockerHubRegistry = "https://index.docker.io/v1/"
+
+// RegistryAuth are the credentials used to pull images from a private registry (See Options.RegistryAuth).
+type RegistryAuth struct {
+	Username string
+	Password string
+	// Token is an identity token, used instead of Username and Password (eg: cloud registries).
+	Token string
+}
+
+// dockerConfig is the subset of the docker CLI configuration file (~/.docker/config.json) used by this package: registries credentials, and current context.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth          string `json:"auth"`
+		IdentityToken string `json:"identitytoken"`
+	} `json:"auths"`
+	CredsStore     string            `json:"credsStore"`
+	CredHelpers    map[string]string `json:"credHelpers"`
+	CurrentContext string            `json:"currentContext"`
+}
+
+// ConfiguredRegistryAuth return the credentials of the docker CLI configuration (~/.docker/config.json, or DOCKER_CONFIG) for the registry hosting the given image, including credential helpers. Nil is returned if no credentials are found.
+// These are the credentials used to pull the image when Options.RegistryAuth is not specified.
+func ConfiguredRegistryAuth(image string) (*RegistryAuth, error) {
+	registry := registryOf(image)
+	auth, err := configuredAuth(registry)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Loading credentials for %s", registry)
+	}
+	return auth, nil
+}
+
+// registryAuth return the encoded credentials to use to pull the given image: Options.RegistryAuth if specified, the credentials of the docker CLI configuration otherwise (including credential helpers). An empty string is returned if no credentials are found.
+func registryAuth(options Options) (string, error) {
+	registry := registryOf(options.Image)
+	auth := options.RegistryAuth
+	if nil == auth {
+		var err error
+		auth, err = ConfiguredRegistryAuth(options.Image)
+		if nil != err {
+			return "", err
+		}
+		if nil == auth {
+			return "", nil
+		}
+	}
+	encoded, err := json.Marshal(types.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		IdentityToken: auth.Token,
+		ServerAddress: registry,
+	})
+	if nil != err {
+		return "", errors.Wrap(err, "Encoding registry credentials")
+	}
+	return base64.URLEncoding.EncodeToString(encoded), nil
+}
+
+// registryOf return the registry hosting the given image (the docker configuration key for Docker Hub images).
+func registryOf(reference string) string {
+	index := strings.Index(reference, "/")
+	if -1 == index {
+		return dockerHubRegistry
+	}
+	host := reference[:index]
+	if !strings.ContainsAny(host, ".:") && "localhost" != host {
+		return dockerHubRegistry
+	}
+	if "docker.io" == host || "index.docker.io" == host {
+		return dockerHubRegistry
+	}
+	return host
+}
+
+// configuredAuth return the credentials of the docker CLI configuration (See DOCKER_CONFIG) for the given registry, or nil if there is none.
+func configuredAuth(registry string) (*RegistryAuth, error) {
+	directory, err := dockerConfigDir()
+	if nil != err {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(directory, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if nil != err {
+		return nil, errors.Wrap(err, "Reading docker configuration")
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(content, &config); nil != err {
+		return nil, errors.Wrap(err, "Parsing docker configuration")
+	}
+
+	if helper, ok := config.CredHelpers[registry]; ok {
+		return helperAuth(helper, registry)
+	}
+	if "" != config.CredsStore {
+		return helperAuth(config.CredsStore, registry)
+	}
+	entry, ok := config.Auths[registry]
+	if !ok {
+		return nil, nil
+	}
+	if "" != entry.IdentityToken {
+		return &RegistryAuth{Token: entry.IdentityToken}, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Decoding credentials of %s", registry)
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if 2 != len(parts) {
+		return nil, errors.New("Invalid credentials format for " + registry)
+	}
+	return &RegistryAuth{Username: parts[0], Password: parts[1]}, nil
+}
+
+// helperAuth get the credentials of the registry from a docker credential helper (docker-credential-<helper>). Nil is returned if the helper has no credentials for the registry.
+func helperAuth(helper string, registry string) (*RegistryAuth, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(registry)
+	output, err := cmd.Output()
+	if nil != err {
+		if bytes.Contains(output, []byte("credentials not found")) {
+			return nil, nil
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok && bytes.Contains(exitErr.Stderr, []byte("credentials not found")) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "Executing credential helper %s", helper)
+	}
+	var credentials struct {
+		Username string
+		Secret   string
+	}
+	if err := json.Unmarshal(output, &credentials); nil != err {
+		return nil, errors.Wrapf(err, "Parsing output of credential helper %s", helper)
+	}
+	if "<token>" == credentials.Username {
+		return &RegistryAuth{Token: credentials.Secret}, nil
+	}
+	return &RegistryAuth{Username: credentials.Username, Password: credentials.Secret}, nil
+}
+
+// dockerConfigDir return the directory of the docker CLI configuration: DOCKER_CONFIG, or ~/.docker.
+func dockerConfigDir() (string, error) {
+	if directory := os.Getenv("DOCKER_CONFIG"); "" != directory {
+		return directory, nil
+	}
+	home, err := os.UserHomeDir()
+	if nil != err {
+		return "", errors.Wrap(err, "Finding home directory")
+	}
+	return filepath.Join(home, ".docker"), nil
+}
@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// LabelReuse is the label holding the reuse key of the containers created with Options.Reuse.
+const LabelReuse = "org.normegil.docker.reuse"
+
+// reuseKey return a deterministic key identifying the configuration of the container: containers with the same key are interchangeable.
+func reuseKey(options Options) string {
+	configuration, _ := json.Marshal(struct {
+		Name        string
+		Image       string
+		Environment []string
+		Labels      map[string]string
+		Ports       []PortBinding
+		Binds       []Bind
+		Tmpfs       map[string]string
+		Readonly    bool
+		Init        bool
+		Tty         bool
+		Files       []File
+		Secrets     []Secret
+		Platform    string
+	}{
+		Name:        options.Name,
+		Image:       options.Image,
+		Environment: sortedEnvironment(options),
+		Labels:      options.Labels,
+		Ports:       options.Ports,
+		Binds:       options.Binds,
+		Tmpfs:       options.TmpfsMounts,
+		Readonly:    options.ReadonlyRootfs,
+		Init:        options.Init,
+		Tty:         options.Tty,
+		Files:       options.Files,
+		Secrets:     options.Secrets,
+		Platform:    options.Platform,
+	})
+	hash := sha256.Sum256(configuration)
+	return hex.EncodeToString(hash[:])
+}
+
+// newContainerName return the name of a new container: the name from the options, suffixed by a random identifier, or by the reuse key for reused containers.
+// Reused containers having a deterministic name, the daemon guarantee that only one of them is created, even by concurrent test binaries.
+func newContainerName(options Options) (string, error) {
+	if options.Reuse {
+		return options.Name + "-reuse-" + reuseKey(options)[:16], nil
+	}
+	suffix, err := uuid.NewRandom()
+	if nil != err {
+		return "", errors.Wrap(err, "Generating container name suffix")
+	}
+	return options.Name + "-" + suffix.String(), nil
+}
+
+// reuseContainer attach to the existing container created with the same options (See Options.Reuse), starting it if it was stopped, and wait for it to be ready. Nil is returned if there is no such container.
+func reuseContainer(client *docker.Client, options Options, l *eventLogger, image ImageInfo, strategy WaitStrategy, tracker *startupTracker) (*ContainerInfo, string, error) {
+	name, err := newContainerName(options)
+	if nil != err {
+		return nil, "", err
+	}
+	ctx := context.Background()
+	inspected, err := client.ContainerInspect(ctx, name)
+	if docker.IsErrContainerNotFound(err) {
+		return nil, name, nil
+	}
+	if nil != err {
+		return nil, name, errors.Wrapf(err, "Inspecting reusable container %s", name)
+	}
+	if inspected.Config.Labels[LabelReuse] != reuseKey(options) {
+		return nil, name, errors.Errorf("Container %s exists, but was not created with the same options", name)
+	}
+
+	if nil == inspected.State || !inspected.State.Running {
+		l.Printf("Starting reusable container: %s", name)
+		if err := client.ContainerStart(ctx, inspected.ID, types.ContainerStartOptions{}); nil != err && !strings.Contains(err.Error(), "already started") {
+			return nil, name, errors.Wrapf(err, "Starting reusable container %s", name)
+		}
+		if 0 != len(options.Secrets) {
+			// The tmpfs holding the secrets was emptied when the container stopped
+			if err := writeSecrets(ctx, client, inspected.ID, options.Secrets); nil != err {
+				return nil, name, errors.Wrapf(err, "Starting reusable container %s", name)
+			}
+		}
+		if inspected, err = client.ContainerInspect(ctx, name); nil != err {
+			return nil, name, errors.Wrapf(err, "Inspecting reusable container %s", name)
+		}
+	}
+
+	_, ip := hostAddresses(options)
+	ports, err := inspectedPorts(inspected, options.Ports)
+	if nil != err {
+		return nil, name, err
+	}
+	info := &ContainerInfo{
+		Identifier: inspected.ID,
+		Address:    ip,
+		Ports:      ports,
+		Image:      image,
+	}
+	if options.PublishAllPorts {
+		if err := addPublishedPorts(client, info.Identifier, info.Ports); nil != err {
+			return nil, name, errors.Wrap(err, "Could not list published ports")
+		}
+	}
+	if err := reachFromContainer(client, options, info); nil != err {
+		return nil, name, errors.Wrap(err, "Could not find container address")
+	}
+	l.Printf("Reusing container: " + name)
+	if err := waitContainer(client, *info, strategy, startupTimeout(options), withDefaultRetries(options), tracker); nil != err {
+		return nil, name, lifecycleError(PhaseWait, name, options, errors.Wrap(err, "Reused container not ready within time limit"))
+	}
+	return info, name, nil
+}
+
+// startReusableContainer attach to the container created with the same options, or create it. If another process is creating it concurrently, wait for this process to create it and attach to it.
+func startReusableContainer(client *docker.Client, options Options, l *eventLogger, image ImageInfo, strategy WaitStrategy, tracker *startupTracker) (*ContainerInfo, string, error) {
+	deadline := time.Now().Add(startupTimeout(options))
+	for {
+		info, name, err := reuseContainer(client, options, l, image, strategy, tracker)
+		if nil != err || nil != info {
+			return info, name, err
+		}
+		info, name, err = startContainer(client, options, l, image, strategy, tracker)
+		if nil == err || !isNameConflict(err) || time.Now().After(deadline) {
+			return info, name, err
+		}
+		l.Printf("Reusable container %s created concurrently, attaching to it", name)
+		time.Sleep(probeWaitTime)
+	}
+}
+
+// isNameConflict check if the container creation failed because the name is already used.
+func isNameConflict(err error) bool {
+	return strings.Contains(err.Error(), "is already in use")
+}
+
+// inspectedPorts return the host ports on which the given bindings are published, from the inspected container.
+func inspectedPorts(inspected types.ContainerJSON, bindings []PortBinding) (map[PortBinding]int, error) {
+	ports := make(map[PortBinding]int)
+	if nil == inspected.NetworkSettings {
+		return ports, nil
+	}
+	for _, binding := range bindings {
+		published := inspected.NetworkSettings.Ports[binding.dockerPort(binding.Internal)]
+		if 0 == len(published) {
+			return nil, errors.Errorf("Port %d/%s is not published by container %s", binding.Internal, binding.protocol(), inspected.Name)
+		}
+		hostPort, err := strconv.Atoi(published[0].HostPort)
+		if nil != err {
+			return nil, errors.Wrapf(err, "Parsing host port of %d/%s", binding.Internal, binding.protocol())
+		}
+		ports[binding] = hostPort
+	}
+	return ports, nil
+}
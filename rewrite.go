@@ -0,0 +1,54 @@
+package docker
+
+import "strings"
+
+// ImageRewrite is a substitution rule applied to images before pulling them (See Config.ImageRewrites).
+type ImageRewrite struct {
+	// From is the image to replace, or a prefix followed by * (eg: docker.io/*). Docker Hub images are matched by their fully qualified reference (eg: docker.io/library/postgres:13 for postgres:13).
+	From string `yaml:"from"`
+	// To is the replacing image. If From end with *, a * in To is replaced by the rest of the image reference (eg: mirror.corp/*).
+	To string `yaml:"to"`
+}
+
+// apply return the rewritten reference, and true if the rule matched the (qualified) reference.
+func (r ImageRewrite) apply(reference string) (string, bool) {
+	if !strings.HasSuffix(r.From, "*") {
+		return r.To, r.From == reference
+	}
+	prefix := strings.TrimSuffix(r.From, "*")
+	if !strings.HasPrefix(reference, prefix) {
+		return "", false
+	}
+	return strings.Replace(r.To, "*", strings.TrimPrefix(reference, prefix), 1), true
+}
+
+// rewriteImage apply the first matching rule to the image. The image is unchanged if no rule match.
+func rewriteImage(reference string, rules []ImageRewrite) string {
+	if "" == reference {
+		return reference
+	}
+	qualified := qualifiedImage(reference)
+	for _, rule := range rules {
+		if rewritten, ok := rule.apply(qualified); ok {
+			return rewritten
+		}
+		if rewritten, ok := rule.apply(reference); ok {
+			return rewritten
+		}
+	}
+	return reference
+}
+
+// qualifiedImage return the fully qualified reference of Docker Hub images (eg: postgres:13 -> docker.io/library/postgres:13). References of other registries are unchanged.
+func qualifiedImage(reference string) string {
+	if dockerHubRegistry != registryOf(reference) {
+		return reference
+	}
+	for _, prefix := range []string{"docker.io/", "index.docker.io/"} {
+		reference = strings.TrimPrefix(reference, prefix)
+	}
+	if !strings.Contains(repositoryOf(reference), "/") {
+		reference = "library/" + reference
+	}
+	return "docker.io/" + reference
+}
@@ -0,0 +1,46 @@
+package docker
+
+import "testing"
+
+func TestRewriteImage(t *testing.T) {
+	rules := []ImageRewrite{
+		{From: "docker.io/library/redis:6", To: "cache.corp/redis:6-patched"},
+		{From: "quay.io/*", To: "quay-mirror.corp/*"},
+		{From: "docker.io/*", To: "mirror.corp/*"},
+	}
+	for _, test := range []struct {
+		image    string
+		expected string
+	}{
+		{image: "redis:6", expected: "cache.corp/redis:6-patched"},
+		{image: "redis:7", expected: "mirror.corp/library/redis:7"},
+		{image: "postgres", expected: "mirror.corp/library/postgres"},
+		{image: "bitnami/kafka:3", expected: "mirror.corp/bitnami/kafka:3"},
+		{image: "docker.io/library/postgres:13", expected: "mirror.corp/library/postgres:13"},
+		{image: "index.docker.io/library/postgres:13", expected: "mirror.corp/library/postgres:13"},
+		{image: "quay.io/keycloak/keycloak:24", expected: "quay-mirror.corp/keycloak/keycloak:24"},
+		{image: "ghcr.io/org/image:1", expected: "ghcr.io/org/image:1"},
+		{image: "localhost:5000/image", expected: "localhost:5000/image"},
+		{image: "", expected: ""},
+	} {
+		if rewritten := rewriteImage(test.image, rules); test.expected != rewritten {
+			t.Errorf("%s: expected %s, got %s", test.image, test.expected, rewritten)
+		}
+	}
+}
+
+func TestRewriteImageFirstRule(t *testing.T) {
+	rules := []ImageRewrite{
+		{From: "docker.io/library/*", To: "first.corp/*"},
+		{From: "docker.io/*", To: "second.corp/*"},
+	}
+	if rewritten := rewriteImage("postgres:13", rules); "first.corp/postgres:13" != rewritten {
+		t.Errorf("Expected the first matching rule to be applied, got %s", rewritten)
+	}
+}
+
+func TestRewriteImageWithoutRules(t *testing.T) {
+	if rewritten := rewriteImage("postgres:13", nil); "postgres:13" != rewritten {
+		t.Errorf("Expected the image to be unchanged, got %s", rewritten)
+	}
+}
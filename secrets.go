@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// SecretsDirectory is the directory of the container in which secrets are written (See Options.Secrets), like docker swarm and compose secrets. It is a tmpfs.
+const SecretsDirectory = "/run/secrets"
+
+// secretsReady is the file marking the secrets as written: the command of the container wait for it before running.
+const secretsReady = SecretsDirectory + "/.ready"
+
+// awaitSecrets is the beginning of the entrypoint of the containers with secrets: it wait for the secrets to be written, and run the command given as arguments.
+var awaitSecrets = []string{"/bin/sh", "-c", "while [ ! -f " + secretsReady + " ]; do sleep 0.1; done; exec \"$@\"", "sh"}
+
+// Secret is a credential made available to the container as a file of SecretsDirectory, readable only by its owner (mode 0400), instead of an environment variable visible in `docker inspect` and to every process of the container.
+// Images supporting the _FILE convention read them through variables like POSTGRES_PASSWORD_FILE=/run/secrets/db-password.
+type Secret struct {
+	// Name of the file, in SecretsDirectory.
+	Name string
+	// Value of the secret.
+	Value []byte
+	// UID and GID owning the file. Default to root: services not running as root need the file to be owned by their user (eg: 999 for postgres).
+	UID int
+	GID int
+}
+
+// Path return the path of the secret file inside the container.
+func (s Secret) Path() string {
+	return path.Join(SecretsDirectory, s.Name)
+}
+
+// secretsMount return the options of the tmpfs mounted on SecretsDirectory.
+func secretsMount() string {
+	return "rw,noexec,nosuid,mode=0755"
+}
+
+// writeSecrets write the secrets in the tmpfs of the started container, then mark them as written so that its command run.
+// The daemon cannot copy files into a tmpfs: they are written through commands executed as root in the container, receiving the values on their standard input, so that the values never appear in a command line.
+// The tmpfs is emptied when the container stop: the secrets are written again when the container is started again (See Container.resume).
+func writeSecrets(ctx context.Context, client *docker.Client, containerID string, secrets []Secret) error {
+	for _, secret := range secrets {
+		owner := strconv.Itoa(secret.UID) + ":" + strconv.Itoa(secret.GID)
+		cmd := []string{"/bin/sh", "-c", `umask 077 && cat > "$0" && chown "$1" "$0" && chmod 0400 "$0"`, secret.Path(), owner}
+		if err := executeAsRoot(ctx, client, containerID, cmd, secret.Value); nil != err {
+			return errors.Wrapf(err, "Writing secret %s", secret.Name)
+		}
+	}
+	if err := executeAsRoot(ctx, client, containerID, []string{"/bin/sh", "-c", ": > " + secretsReady}, nil); nil != err {
+		return errors.Wrap(err, "Marking secrets as written")
+	}
+	return nil
+}
+
+// executeAsRoot run the given command as root inside the container, with the given standard input, and fail if it doesn't exit successfully.
+func executeAsRoot(ctx context.Context, client *docker.Client, containerID string, cmd []string, input []byte) error {
+	config := types.ExecConfig{
+		User:         "0",
+		Cmd:          cmd,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	code, output, err := executeConfig(ctx, client, containerID, config, bytes.NewReader(input))
+	if nil != err {
+		return err
+	}
+	if 0 != code {
+		return errors.Errorf("Exit code %d: %s", code, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// serializedPort is a published port of a serialized ContainerInfo (JSON objects keys cannot be PortBindings).
+type serializedPort struct {
+	Binding  PortBinding `json:"binding"`
+	HostPort int         `json:"hostPort"`
+}
+
+type serializedInfo struct {
+	Identifier string           `json:"id"`
+	Address    string           `json:"address"`
+	Ports      []serializedPort `json:"ports"`
+	Image      ImageInfo        `json:"image"`
+	Direct     bool             `json:"direct,omitempty"`
+}
+
+// MarshalJSON serialize the container info, to share it with other processes (eg: test binaries started after TestMain).
+func (i ContainerInfo) MarshalJSON() ([]byte, error) {
+	serialized := serializedInfo{
+		Identifier: i.Identifier,
+		Ports:      make([]serializedPort, 0, len(i.Ports)),
+		Image:      i.Image,
+		Direct:     i.Direct,
+	}
+	if nil != i.Address {
+		serialized.Address = i.Address.String()
+	}
+	for binding, hostPort := range i.Ports {
+		serialized.Ports = append(serialized.Ports, serializedPort{Binding: binding, HostPort: hostPort})
+	}
+	return json.Marshal(serialized)
+}
+
+// UnmarshalJSON deserialize a container info serialized by MarshalJSON.
+func (i *ContainerInfo) UnmarshalJSON(data []byte) error {
+	var serialized serializedInfo
+	if err := json.Unmarshal(data, &serialized); nil != err {
+		return err
+	}
+	i.Identifier = serialized.Identifier
+	i.Address = net.ParseIP(serialized.Address)
+	i.Ports = make(map[PortBinding]int, len(serialized.Ports))
+	for _, port := range serialized.Ports {
+		i.Ports[port.Binding] = port.HostPort
+	}
+	i.Image = serialized.Image
+	i.Direct = serialized.Direct
+	return nil
+}
+
+type serializedContainer struct {
+	Info    ContainerInfo `json:"info"`
+	Name    string        `json:"name"`
+	Session string        `json:"session"`
+}
+
+// MarshalJSON serialize the container handle, so that another process can reconnect to the container (See Reconnect).
+func (c *Container) MarshalJSON() ([]byte, error) {
+	return json.Marshal(serializedContainer{Info: c.ContainerInfo, Name: c.Name, Session: SessionID()})
+}
+
+// Reconnect return a handle on a container serialized by another process (See Container.MarshalJSON), after checking that the container still exist (and was not replaced by another one).
+// The container stay owned by the process which created it: Terminate does not remove it.
+func Reconnect(ctx context.Context, data []byte) (*Container, error) {
+	var serialized serializedContainer
+	if err := json.Unmarshal(data, &serialized); nil != err {
+		return nil, errors.Wrap(err, "Parsing serialized container")
+	}
+	client, err := newClient(nil)
+	if nil != err {
+		return nil, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+	inspected, err := client.ContainerInspect(ctx, serialized.Info.Identifier)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting container %s", serialized.Name)
+	}
+	if nil != inspected.Config && "" == inspected.Config.Labels[LabelReuse] && serialized.Session != inspected.Config.Labels[LabelSession] {
+		return nil, errors.Errorf("Container %s was not created by the serialized session %s", serialized.Name, serialized.Session)
+	}
+
+	options := Options{Name: serialized.Name, Image: serialized.Info.Image.Reference}
+	return &Container{
+		ContainerInfo: serialized.Info,
+		Name:          serialized.Name,
+		client:        client,
+		logger:        newLogger(options).with(Fields{"container": serialized.Name}),
+		options:       options,
+		strategy:      forPublishedPorts(),
+		external:      true,
+	}, nil
+}
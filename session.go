@@ -0,0 +1,209 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// Session track the containers, groups, networks and volumes created through it, so that they are all removed by a single call to TerminateAll, instead of collecting every returned handle:
+//
+//	session := &docker.Session{}
+//	defer session.TerminateAll(context.Background())
+//	db, err := session.Start(core.Options{...})
+//
+// A Session is safe for concurrent use, and must not be copied once used.
+type Session struct {
+	// Logger used for the session operations (networks, volumes, teardown). Containers use their own Options.Logger.
+	Logger Logger
+	// Backend on which the networks, and the containers which don't specify their own Options.Backend, are created. Default to DetectBackend().
+	Backend ContainerBackend
+	// Client, if specified, is used to create the networks, and the containers which don't specify their own Options.Client.
+	Client *docker.Client
+
+	mutex        sync.Mutex
+	containers   []*Container
+	environments []*Environment
+	networks     []sessionNetwork
+	volumes      []*Volume
+}
+
+// sessionNetwork is a network created through a Session.
+type sessionNetwork struct {
+	client *docker.Client
+	id     string
+	name   string
+}
+
+// Start start a container (See Start), tracked by the session.
+func (s *Session) Start(options Options) (*Container, error) {
+	c, err := Start(s.withDefaults(options))
+	if nil != err {
+		return nil, err
+	}
+	s.Track(c)
+	return c, nil
+}
+
+// NewAll start containers concurrently (See NewAll), tracked by the session.
+func (s *Session) NewAll(ctx context.Context, options ...Options) ([]*Container, error) {
+	withDefaults := make([]Options, len(options))
+	for i, containerOptions := range options {
+		withDefaults[i] = s.withDefaults(containerOptions)
+	}
+	containers, err := NewAll(ctx, withDefaults...)
+	if nil != err {
+		return nil, err
+	}
+	for _, c := range containers {
+		s.Track(c)
+	}
+	return containers, nil
+}
+
+// StartGroup start the given group (See Group.Start), tracked by the session. The group use the session Backend and Client if it doesn't specify its own.
+func (s *Session) StartGroup(ctx context.Context, group Group) (*Environment, error) {
+	if nil == group.Backend {
+		group.Backend = s.Backend
+	}
+	if nil == group.Client {
+		group.Client = s.Client
+	}
+	environment, err := group.Start(ctx)
+	if nil != err {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.environments = append(s.environments, environment)
+	return environment, nil
+}
+
+// Track add a container started outside of the session (eg: by a module, or FromExisting) to the containers terminated by TerminateAll.
+func (s *Session) Track(c *Container) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.containers = append(s.containers, c)
+}
+
+// CreateNetwork create a bridge network with the given name, tracked by the session, and return its ID. Containers are attached to it through Options.Network.
+func (s *Session) CreateNetwork(ctx context.Context, name string) (string, error) {
+	l := newLogger(Options{Logger: s.Logger})
+	client, err := clientOf(Options{Backend: s.Backend, Client: s.Client}, l)
+	if nil != err {
+		return "", withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+	l.Printf("Creating network: " + name)
+	id, err := createNetwork(ctx, client, name)
+	if nil != err {
+		return "", err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.networks = append(s.networks, sessionNetwork{client: client, id: id, name: name})
+	return id, nil
+}
+
+// NewVolume create a named volume (See NewVolume), tracked by the session. The volume use the session Logger, Backend and Client if the options don't specify their own.
+func (s *Session) NewVolume(ctx context.Context, options VolumeOptions) (*Volume, error) {
+	if nil == options.Logger {
+		options.Logger = s.Logger
+	}
+	if nil == options.Backend {
+		options.Backend = s.Backend
+	}
+	if nil == options.Client {
+		options.Client = s.Client
+	}
+	volume, err := NewVolume(ctx, options)
+	if nil != err {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.volumes = append(s.volumes, volume)
+	return volume, nil
+}
+
+// TerminateAll terminate, in parallel, every container and group of the session, then remove its networks and volumes. Every resource is handled even if others fail, and the returned error list every failure.
+// The session is empty afterwards, and can be reused.
+func (s *Session) TerminateAll(ctx context.Context) error {
+	s.mutex.Lock()
+	containers, environments, networks, volumes := s.containers, s.environments, s.networks, s.volumes
+	s.containers, s.environments, s.networks, s.volumes = nil, nil, nil, nil
+	s.mutex.Unlock()
+
+	l := newLogger(Options{Logger: s.Logger})
+	l.Debugf("Terminating session: %d container(s), %d group(s), %d network(s), %d volume(s)", len(containers), len(environments), len(networks), len(volumes))
+	var mutex sync.Mutex
+	failures := make([]string, 0)
+	fail := func(what string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		failures = append(failures, what+": "+err.Error())
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range containers {
+		wg.Add(1)
+		go func(c *Container) {
+			defer wg.Done()
+			if err := c.Terminate(ctx); nil != err {
+				fail("container "+c.Name, err)
+			}
+		}(c)
+	}
+	for _, environment := range environments {
+		wg.Add(1)
+		go func(environment *Environment) {
+			defer wg.Done()
+			if err := environment.Terminate(ctx); nil != err {
+				fail("group "+environment.Network, err)
+			}
+		}(environment)
+	}
+	wg.Wait()
+
+	// Networks and volumes can only be removed once the containers using them are removed
+	for _, created := range networks {
+		wg.Add(1)
+		go func(created sessionNetwork) {
+			defer wg.Done()
+			l.Printf("Removing network: " + created.name)
+			if err := created.client.NetworkRemove(ctx, created.id); nil != err && !docker.IsErrNetworkNotFound(err) {
+				fail("network "+created.name, err)
+			}
+		}(created)
+	}
+	for _, volume := range volumes {
+		wg.Add(1)
+		go func(volume *Volume) {
+			defer wg.Done()
+			if err := volume.Remove(ctx); nil != err {
+				fail("volume "+volume.Name, err)
+			}
+		}(volume)
+	}
+	wg.Wait()
+
+	if 0 != len(failures) {
+		sort.Strings(failures)
+		return errors.New("Terminating session: " + strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// withDefaults return the options of a container of the session, using the session Backend and Client if the options don't specify their own.
+func (s *Session) withDefaults(options Options) Options {
+	if nil == options.Backend {
+		options.Backend = s.Backend
+	}
+	if nil == options.Client {
+		options.Client = s.Client
+	}
+	return options
+}
@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// self is the container in which the test process is running, if it run inside a container managed by the same daemon (eg: CI jobs running in a container with the docker socket mounted).
+var self *types.ContainerJSON
+var selfOnce sync.Once
+
+// currentContainer return the container in which the test process is running, or nil if it is not running inside a container of the given daemon.
+func currentContainer(client *docker.Client) *types.ContainerJSON {
+	selfOnce.Do(func() {
+		if _, err := os.Stat("/.dockerenv"); nil != err {
+			return
+		}
+		hostname, err := os.Hostname()
+		if nil != err {
+			return
+		}
+		inspected, err := client.ContainerInspect(context.Background(), hostname)
+		if nil != err || nil == inspected.NetworkSettings {
+			return
+		}
+		self = &inspected
+	})
+	return self
+}
+
+// reachFromContainer switch the address of the container to its address on a network shared with the test process container, when the test process run in a sibling container, as the ports published on the host loopback are not reachable from there.
+// The container is then reached directly on its internal ports (See ContainerInfo.Port). Nothing is done if an Address was explicitly specified.
+func reachFromContainer(client *docker.Client, options Options, info *ContainerInfo) error {
+	if nil != options.Address || options.DualStack {
+		return nil
+	}
+	current := currentContainer(client)
+	if nil == current {
+		return nil
+	}
+	inspected, err := client.ContainerInspect(context.Background(), info.Identifier)
+	if nil != err {
+		return errors.Wrap(err, "Inspecting container networks")
+	}
+	if nil == inspected.NetworkSettings {
+		return nil
+	}
+	for name, endpoint := range inspected.NetworkSettings.Networks {
+		if _, shared := current.NetworkSettings.Networks[name]; !shared || nil == endpoint {
+			continue
+		}
+		if address := net.ParseIP(endpoint.IPAddress); nil != address {
+			info.Address = address
+			info.Direct = true
+			return nil
+		}
+	}
+	if address := net.ParseIP(inspected.NetworkSettings.IPAddress); nil != address {
+		info.Address = address
+		info.Direct = true
+	}
+	return nil
+}
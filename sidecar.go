@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/pkg/errors"
+)
+
+// checkSidecarOptions check the options of a sidecar, which cannot have its own network settings.
+func checkSidecarOptions(options Options) error {
+	if 0 != len(options.Ports) || options.PublishAllPorts {
+		return errors.New("Sidecar ports must be declared in the options of the primary container")
+	}
+	if "" != options.Network || 0 != len(options.NetworkAliases) {
+		return errors.New("Sidecars share the network of the primary container, and cannot specify their own")
+	}
+	if options.Reuse || 0 != len(options.Sidecars) || nil != options.FromDockerfile {
+		return errors.New("Sidecars cannot be reused, have sidecars, or be built from a Dockerfile")
+	}
+	return nil
+}
+
+// Sidecars return the sidecars of the container, in the order of Options.Sidecars.
+func (c *Container) Sidecars() []*Container {
+	return c.sidecars
+}
+
+// startSidecars start the sidecars of the container, one after the other. If a sidecar cannot be started, the sidecars already started are removed.
+func (c *Container) startSidecars() error {
+	for i, options := range c.options.Sidecars {
+		if "" == options.Name {
+			options.Name = c.options.Name + "-sidecar-" + strconv.Itoa(i)
+		}
+		if nil == options.Logger {
+			options.Logger = c.options.Logger
+		}
+		loaded, err := LoadConfig()
+		if nil != err {
+			return err
+		}
+		options, err = withEnvFiles(withConfig(options, loaded))
+		if nil != err {
+			return err
+		}
+		sidecar, err := c.startSidecar(options)
+		if nil != err {
+			if terminateErr := c.terminateSidecars(context.Background()); nil != terminateErr {
+				c.logger.Errorf("Could not terminate sidecars of %s: %+v", c.Name, terminateErr)
+			}
+			return err
+		}
+		c.sidecars = append(c.sidecars, sidecar)
+	}
+	return nil
+}
+
+func (c *Container) startSidecar(options Options) (*Container, error) {
+	ctx := context.Background()
+	l := newLogger(options)
+	if err := pullImage(ctx, c.client, options); nil != err {
+		return nil, lifecycleError(PhasePull, options.Name, options, withKind(ErrImagePull, err))
+	}
+	image, err := inspectImage(c.client, options.Image)
+	if nil != err {
+		return nil, lifecycleError(PhasePull, options.Name, options, err)
+	}
+
+	containerName, err := newContainerName(options)
+	if nil != err {
+		return nil, lifecycleError(PhaseCreate, options.Name, options, err)
+	}
+	l = l.with(Fields{"container": containerName})
+	config := hostConfig(options, nil)
+	config.NetworkMode = container.NetworkMode("container:" + c.Identifier)
+	l.with(Fields{"phase": PhaseCreate}).Printf("Creating sidecar of %s: %s", c.Name, containerName)
+	created, err := c.client.ContainerCreate(ctx, containerConfig(options, *image), config, nil, containerName)
+	if nil != err {
+		return nil, lifecycleError(PhaseCreate, containerName, options, errors.Wrap(err, "Could not create sidecar"))
+	}
+	for _, warning := range created.Warnings {
+		l.Warnf("%s", warning)
+	}
+
+	sidecar := &Container{
+		ContainerInfo: ContainerInfo{
+			Identifier: created.ID,
+			Address:    c.Address,
+			Ports:      c.Ports,
+			Image:      *image,
+			Direct:     c.Direct,
+		},
+		Name:     containerName,
+		client:   c.client,
+		logger:   l,
+		options:  options,
+		strategy: options.WaitStrategy,
+	}
+	if nil == sidecar.strategy {
+		sidecar.strategy = ForRunning()
+	}
+	if err := sidecar.prepare(); nil != err {
+		l.Printf("Removing sidecar: " + containerName)
+		if removeErr := c.client.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true}); nil != removeErr {
+			l.Errorf("Could not remove %s: %+v", containerName, removeErr)
+		}
+		return nil, err
+	}
+	l.Printf("Sidecar started: " + containerName)
+	return sidecar, nil
+}
+
+// prepare start a created sidecar, and wait for it to be ready.
+func (c *Container) prepare() error {
+	if 0 != len(c.options.Files) {
+		c.logger.Printf("Copying files in container: " + c.Name)
+		if err := copyFiles(c.client, c.Identifier, c.options.Files); nil != err {
+			return lifecycleError(PhaseCreate, c.Name, c.options, errors.Wrap(err, "Could not copy files in container"))
+		}
+	}
+	c.logger.with(Fields{"phase": PhaseStart}).Printf("Starting container: " + c.Name)
+	if err := c.client.ContainerStart(context.Background(), c.Identifier, types.ContainerStartOptions{}); nil != err {
+		return lifecycleError(PhaseStart, c.Name, c.options, errors.Wrap(err, "Could not start container"))
+	}
+	if 0 != len(c.options.Secrets) {
+		c.logger.Printf("Writing secrets in container: " + c.Name)
+		if err := writeSecrets(context.Background(), c.client, c.Identifier, c.options.Secrets); nil != err {
+			return lifecycleError(PhaseStart, c.Name, c.options, err)
+		}
+	}
+	c.logger.with(Fields{"phase": PhaseWait}).Printf("Waiting for container: " + c.Name)
+	peak := followMemoryPeak(c.client, c.Identifier, c.options)
+	if err := waitContainer(c.client, c.ContainerInfo, c.strategy, startupTimeout(c.options), withDefaultRetries(c.options), nil); nil != err {
+		return lifecycleError(PhaseWait, c.Name, c.options, startupFailure(c.client, c.Identifier, peak(), errors.Wrap(err, "Container not started within time limit")))
+	}
+	peak()
+	return nil
+}
+
+// terminateSidecars remove the sidecars of the container, before the container itself: a sidecar cannot outlive the network namespace it joined.
+func (c *Container) terminateSidecars(ctx context.Context) error {
+	failures := make([]string, 0)
+	for _, sidecar := range c.sidecars {
+		if err := sidecar.Terminate(ctx); nil != err {
+			failures = append(failures, sidecar.Name+": "+err.Error())
+		}
+	}
+	c.sidecars = nil
+	if 0 != len(failures) {
+		return errors.New("Terminating sidecars: " + strings.Join(failures, "; "))
+	}
+	return nil
+}
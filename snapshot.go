@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/pkg/errors"
+)
+
+// defaultSnapshotStopTimeout is the time given to the container to stop gracefully before a snapshot or a restoration of its volume, when Options.StopTimeout is not specified.
+const defaultSnapshotStopTimeout = 10 * time.Second
+
+// SnapshotVolume archive the volume mounted on the given path of the container (eg: the data directory of a seeded database, See DataDirectory), to reset it later to this state (See RestoreVolume). Named and anonymous volumes are supported, tmpfs mounts are not.
+// The container is gracefully stopped during the snapshot, so that the service flush its data, then started again and waited for.
+func (c *Container) SnapshotVolume(ctx context.Context, mountPath string) (*VolumeSnapshot, error) {
+	volume, err := c.mountedVolume(ctx, mountPath)
+	if nil != err {
+		return nil, err
+	}
+	var snapshot *VolumeSnapshot
+	err = c.stopped(ctx, func() error {
+		var err error
+		snapshot, err = volume.Snapshot(ctx)
+		return err
+	})
+	if nil != err {
+		return nil, err
+	}
+	snapshot.Path = path.Clean(mountPath)
+	return snapshot, nil
+}
+
+// RestoreVolume reset the volume mounted in the container to the content of the snapshot (See SnapshotVolume), without recreating the container: tests can then start each from the same seeded database.
+// The container is stopped during the restoration, then started again and waited for.
+func (c *Container) RestoreVolume(ctx context.Context, snapshot *VolumeSnapshot) error {
+	if "" == snapshot.Path {
+		return errors.New("Snapshot of a Volume, restore it with Volume.Restore")
+	}
+	volume, err := c.mountedVolume(ctx, snapshot.Path)
+	if nil != err {
+		return err
+	}
+	return c.stopped(ctx, func() error {
+		return volume.Restore(ctx, snapshot)
+	})
+}
+
+// mountedVolume return the volume mounted on the given path of the container.
+func (c *Container) mountedVolume(ctx context.Context, mountPath string) (*Volume, error) {
+	inspected, err := c.client.ContainerInspect(ctx, c.Identifier)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Inspecting %s", c.Name)
+	}
+	for _, mounted := range inspected.Mounts {
+		if path.Clean(mounted.Destination) != path.Clean(mountPath) {
+			continue
+		}
+		if mount.TypeVolume != mounted.Type && "" == mounted.Name {
+			return nil, errors.Errorf("%s of %s is not a volume (Type: %s)", mountPath, c.Name, mounted.Type)
+		}
+		return &Volume{Name: mounted.Name, client: c.client, logger: c.logger, backend: c.options.Backend}, nil
+	}
+	return nil, errors.Errorf("No volume mounted on %s in %s", mountPath, c.Name)
+}
+
+// stopped gracefully stop the container, call operation, and start the container again, waiting for it to be ready.
+func (c *Container) stopped(ctx context.Context, operation func() error) error {
+	timeout := c.options.StopTimeout
+	if 0 == timeout {
+		timeout = defaultSnapshotStopTimeout
+	}
+	if err := c.Stop(ctx, timeout); nil != err {
+		return err
+	}
+	operationErr := operation()
+	c.logger.Printf("Starting container: " + c.Name)
+	if err := c.client.ContainerStart(ctx, c.Identifier, types.ContainerStartOptions{}); nil != err {
+		return errors.Wrapf(err, "Starting %s", c.Name)
+	}
+	if err := c.resume(true); nil != err {
+		return err
+	}
+	return operationErr
+}
@@ -0,0 +1,141 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/pkg/errors"
+)
+
+// Stats is a sample of the resources consumed by a container (See Container.Stats).
+type Stats struct {
+	// Time at which the sample was taken by the daemon.
+	Time time.Time
+	// CPUPercent is the CPU usage since the previous sample, where 100 is one full CPU (like `docker stats`).
+	CPUPercent float64
+	// MemoryUsage is the memory used by the container, in bytes, excluding the page cache.
+	MemoryUsage uint64
+	// MemoryPeak is the maximum memory used by the container since its creation, in bytes. It is not reported on cgroup v2 hosts.
+	MemoryPeak uint64
+	// MemoryLimit is the memory available to the container, in bytes (the host memory if no limit was specified, See Resources.Memory).
+	MemoryLimit uint64
+	// NetworkReceived and NetworkSent are the bytes received and sent on all the network interfaces of the container.
+	NetworkReceived uint64
+	NetworkSent     uint64
+	// BlockRead and BlockWritten are the bytes read from and written to the block devices.
+	BlockRead    uint64
+	BlockWritten uint64
+}
+
+// rawStats is the subset of the stats sent by the daemon used by this package. It is decoded separately from types.StatsJSON, to read the fields the vendored API version doesn't know (eg: online_cpus).
+type rawStats struct {
+	Read        time.Time                     `json:"read"`
+	CPUStats    cpuStats                      `json:"cpu_stats"`
+	PreCPUStats cpuStats                      `json:"precpu_stats"`
+	MemoryStats types.MemoryStats             `json:"memory_stats"`
+	Networks    map[string]types.NetworkStats `json:"networks"`
+	BlkioStats  types.BlkioStats              `json:"blkio_stats"`
+}
+
+// cpuStats add to types.CPUStats the number of CPUs available to the container, sent by recent daemons instead of the per-CPU usage.
+type cpuStats struct {
+	types.CPUStats
+	OnlineCPUs uint32 `json:"online_cpus"`
+}
+
+// Stats return a single sample of the resources consumed by the container. The daemon need about a second to compute the CPU usage.
+func (c *Container) Stats(ctx context.Context) (Stats, error) {
+	response, err := c.client.ContainerStats(ctx, c.Identifier, false)
+	if nil != err {
+		return Stats{}, errors.Wrapf(err, "Getting stats of %s", c.Name)
+	}
+	defer response.Body.Close()
+	var raw rawStats
+	if err := json.NewDecoder(response.Body).Decode(&raw); nil != err {
+		return Stats{}, errors.Wrapf(err, "Decoding stats of %s", c.Name)
+	}
+	return toStats(raw), nil
+}
+
+// StreamStats deliver on the returned channel a sample of the resources consumed by the container every second, until ctx is done or the container stop. The channel is then closed.
+func (c *Container) StreamStats(ctx context.Context) (<-chan Stats, error) {
+	response, err := c.client.ContainerStats(ctx, c.Identifier, true)
+	if nil != err {
+		return nil, errors.Wrapf(err, "Streaming stats of %s", c.Name)
+	}
+	samples := make(chan Stats)
+	go func() {
+		defer close(samples)
+		defer response.Body.Close()
+		decoder := json.NewDecoder(response.Body)
+		for {
+			var raw rawStats
+			if err := decoder.Decode(&raw); nil != err {
+				if io.EOF != err && nil == ctx.Err() {
+					c.logger.Warnf("Stats stream of %s interrupted: %+v", c.Name, err)
+				}
+				return
+			}
+			select {
+			case samples <- toStats(raw):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return samples, nil
+}
+
+// toStats compute the figures of the sample, like the docker CLI do.
+func toStats(raw rawStats) Stats {
+	stats := Stats{
+		Time:        raw.Read,
+		CPUPercent:  cpuPercent(raw.CPUStats, raw.PreCPUStats),
+		MemoryUsage: raw.MemoryStats.Usage,
+		MemoryPeak:  raw.MemoryStats.MaxUsage,
+		MemoryLimit: raw.MemoryStats.Limit,
+	}
+	// Page cache can be reclaimed by the kernel, and is not counted in the usage (cgroup v1: total_inactive_file, cgroup v2: inactive_file)
+	for _, key := range []string{"total_inactive_file", "inactive_file"} {
+		if inactive, ok := raw.MemoryStats.Stats[key]; ok {
+			if inactive < stats.MemoryUsage {
+				stats.MemoryUsage -= inactive
+			}
+			break
+		}
+	}
+	for _, network := range raw.Networks {
+		stats.NetworkReceived += network.RxBytes
+		stats.NetworkSent += network.TxBytes
+	}
+	for _, entry := range raw.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			stats.BlockRead += entry.Value
+		case "write":
+			stats.BlockWritten += entry.Value
+		}
+	}
+	return stats
+}
+
+// cpuPercent return the CPU usage between the two samples, where 100 is one full CPU.
+func cpuPercent(current cpuStats, previous cpuStats) float64 {
+	cpuDelta := float64(current.CPUUsage.TotalUsage) - float64(previous.CPUUsage.TotalUsage)
+	systemDelta := float64(current.SystemUsage) - float64(previous.SystemUsage)
+	if 0 >= cpuDelta || 0 >= systemDelta {
+		return 0
+	}
+	cpus := float64(current.OnlineCPUs)
+	if 0 == cpus {
+		cpus = float64(len(current.CPUUsage.PercpuUsage))
+	}
+	if 0 == cpus {
+		cpus = 1
+	}
+	return cpuDelta / systemDelta * cpus * 100
+}
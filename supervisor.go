@@ -0,0 +1,116 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// Supervisor watches containers while tests are running, and react when one of them dies unexpectedly.
+// Without supervision, a crashed dependency usually shows up as a test timing out without any explanation.
+// A single Supervisor can be shared by multiple containers (See Options.Supervisor).
+type Supervisor struct {
+	// Restart, if true, will restart a container that died and wait for it to be reachable again.
+	Restart bool
+	// OnFailure is called when a supervised container died and could not be restarted (or Restart is false).
+	// It is called from a separate goroutine: use t.Error (not t.Fatal) or cancel a context shared with the test.
+	OnFailure func(info ContainerInfo, err error)
+}
+
+// supervised gather what a Supervisor need to know about a container.
+type supervised struct {
+	client   *docker.Client
+	logger   *eventLogger
+	info     ContainerInfo
+	name     string
+	strategy WaitStrategy
+	timeout  time.Duration
+	retries  Retries
+	// secrets are written again in the restarted container (See Options.Secrets).
+	secrets []Secret
+}
+
+// watch start supervising the given container. The returned function stop the supervision, and should be called before removing the container.
+func (s *Supervisor) watch(c supervised) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.supervise(ctx, c)
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func (s *Supervisor) supervise(ctx context.Context, c supervised) {
+	args := filters.NewArgs()
+	args.Add("type", "container")
+	args.Add("container", c.info.Identifier)
+	args.Add("event", "die")
+
+	since := time.Now()
+	for {
+		messages, errs := c.client.Events(ctx, types.EventsOptions{
+			Since:   strconv.FormatInt(since.Unix(), 10),
+			Filters: args,
+		})
+	stream:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-errs:
+				if nil != ctx.Err() {
+					return
+				}
+				c.logger.Warnf("Supervisor: event stream interrupted for %s: %+v", c.name, err)
+				time.Sleep(stepWaitTime)
+				break stream
+			case event := <-messages:
+				since = time.Unix(0, event.TimeNano).Add(time.Second)
+				c.logger.Errorf("Supervisor: container died: %s (Exit code: %s)", c.name, event.Actor.Attributes["exitCode"])
+				if err := s.recover(ctx, c); nil != err {
+					if nil != ctx.Err() {
+						return
+					}
+					if nil != s.OnFailure {
+						s.OnFailure(c.info, err)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Supervisor) recover(ctx context.Context, c supervised) error {
+	died := fmt.Errorf("Container died: %s", c.name)
+	if !s.Restart {
+		return died
+	}
+
+	c.logger.Printf("Supervisor: restarting container: %s", c.name)
+	if err := c.client.ContainerStart(ctx, c.info.Identifier, types.ContainerStartOptions{}); nil != err {
+		return errors.Wrapf(err, "Restarting %s", c.name)
+	}
+	if 0 != len(c.secrets) {
+		if err := writeSecrets(ctx, c.client, c.info.Identifier, c.secrets); nil != err {
+			return errors.Wrapf(err, "Restarting %s", c.name)
+		}
+	}
+	if err := waitContainer(c.client, c.info, c.strategy, c.timeout, c.retries, nil); nil != err {
+		return errors.Wrapf(err, "Restarted container %s not ready", c.name)
+	}
+	c.logger.Printf("Supervisor: container restarted: %s", c.name)
+	return nil
+}
@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+
+	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// TemplateData is the data available to the templates of the options (See Options.Templates).
+type TemplateData struct {
+	// Name of the container, including its unique suffix.
+	Name string
+	// Host is the address on which the test process reach the container (eg: 127.0.0.1).
+	Host string
+	// Network is the network of the container (See Options.Network), and Aliases are the names of the container on it.
+	Network string
+	Aliases []string
+	// Peers are the aliases on Network of the other containers attached to it when the container is created (eg: the members of a Group it depends on), indexed by container name.
+	Peers map[string][]string
+
+	ports map[PortBinding]int
+}
+
+// Port return the host port on which the given internal port is published, for the TCP protocol unless another protocol is specified (eg: {{.Port 53 "udp"}}).
+// Ports assigned by the daemon (See Options.PublishAllPorts) are only known once the container is started, and cannot be used.
+func (d TemplateData) Port(internal int, protocol ...string) (int, error) {
+	wanted := ProtocolTCP
+	if 0 != len(protocol) {
+		wanted = protocol[0]
+	}
+	info := ContainerInfo{Ports: d.ports}
+	for binding := range d.ports {
+		if wanted != binding.protocol() {
+			continue
+		}
+		if port := info.HostPort(binding, internal); 0 != port {
+			return port, nil
+		}
+	}
+	return 0, errors.Errorf("Port %d/%s is not published on a host port selected before the creation of the container", internal, wanted)
+}
+
+// Endpoint return the "host:port" address on which the test process reach the given internal TCP port (eg: PLAINTEXT://{{.Endpoint 9092}}).
+func (d TemplateData) Endpoint(internal int) (string, error) {
+	port, err := d.Port(internal)
+	if nil != err {
+		return "", err
+	}
+	return net.JoinHostPort(d.Host, strconv.Itoa(port)), nil
+}
+
+// templateData return the data of the templates of the container.
+func templateData(client *docker.Client, options Options, name string, address net.IP, ports map[PortBinding]int) (TemplateData, error) {
+	data := TemplateData{
+		Name:    name,
+		Host:    address.String(),
+		Network: options.Network,
+		Aliases: options.NetworkAliases,
+		Peers:   make(map[string][]string),
+		ports:   ports,
+	}
+	if "" == options.Network {
+		return data, nil
+	}
+	ctx := context.Background()
+	inspected, err := client.NetworkInspect(ctx, options.Network)
+	if nil != err {
+		return data, errors.Wrapf(err, "Inspecting network %s", options.Network)
+	}
+	for id := range inspected.Containers {
+		peer, err := client.ContainerInspect(ctx, id)
+		if nil != err {
+			return data, errors.Wrapf(err, "Inspecting container %s", id)
+		}
+		if nil == peer.NetworkSettings || nil == peer.NetworkSettings.Networks[options.Network] {
+			continue
+		}
+		data.Peers[strings.TrimPrefix(peer.Name, "/")] = peer.NetworkSettings.Networks[options.Network].Aliases
+	}
+	return data, nil
+}
+
+// expandTemplates return the options, with the templates of EnvironmentVariables, Cmd and Entrypoint executed with the given data (See Options.Templates).
+func expandTemplates(options Options, data TemplateData) (Options, error) {
+	variables := make(map[string]string, len(options.EnvironmentVariables))
+	for key, value := range options.EnvironmentVariables {
+		expanded, err := expandTemplate("variable "+key, value, data)
+		if nil != err {
+			return options, err
+		}
+		variables[key] = expanded
+	}
+	options.EnvironmentVariables = variables
+	var err error
+	if options.Cmd, err = expandArguments("command", options.Cmd, data); nil != err {
+		return options, err
+	}
+	if options.Entrypoint, err = expandArguments("entrypoint", options.Entrypoint, data); nil != err {
+		return options, err
+	}
+	return options, nil
+}
+
+// expandArguments execute the templates of the arguments of a command.
+func expandArguments(name string, arguments []string, data TemplateData) ([]string, error) {
+	if nil == arguments {
+		return nil, nil
+	}
+	expanded := make([]string, len(arguments))
+	for i, argument := range arguments {
+		var err error
+		if expanded[i], err = expandTemplate(name+" argument "+strconv.Itoa(i), argument, data); nil != err {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// expandTemplate execute the given template with the data. Name identify the template in the returned errors.
+func expandTemplate(name string, text string, data TemplateData) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	parsed, err := template.New(name).Option("missingkey=error").Parse(text)
+	if nil != err {
+		return "", errors.Wrapf(err, "Parsing template of %s", name)
+	}
+	var expanded bytes.Buffer
+	if err := parsed.Execute(&expanded, data); nil != err {
+		return "", errors.Wrapf(err, "Executing template of %s", name)
+	}
+	return expanded.String(), nil
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"context"
+	"sync"
+)
+
+// TestingT is the subset of testing.TB used to forward container logs to a test (See Container.LogToTest).
+type TestingT interface {
+	Logf(format string, args ...interface{})
+	Failed() bool
+	Cleanup(func())
+}
+
+// LogToTest collect the container logs during the test, and forward them to t.Logf, prefixed by the container name, only if the test failed.
+// Passing tests output stay clean, while failing tests show what the service was doing. The logs are forwarded when the test (and its subtests) completed.
+func (c *Container) LogToTest(t TestingT) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mutex sync.Mutex
+	logs := make([]Log, 0)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := c.FollowLogs(ctx, LogConsumerFunc(func(log Log) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			logs = append(logs, log)
+		})); nil != err {
+			c.logger.Warnf("Could not collect logs of %s: %+v", c.Name, err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		if !t.Failed() {
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, log := range logs {
+			t.Logf("[%s] %s: %s", c.Name, log.Stream, log.Content)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// StartupTimings are the durations of the phases of a container startup (See Container.Timings).
+type StartupTimings struct {
+	// Pull is the time spent pulling (or building) the image. It is almost 0 for images already available locally.
+	Pull time.Duration
+	// Create is the time spent creating the container.
+	Create time.Duration
+	// Start is the time spent starting the container.
+	Start time.Duration
+	// Ready is the time spent waiting for the service inside the container to be ready (See Options.WaitStrategy).
+	Ready time.Duration
+	// Total is the duration of the whole startup, including the phases not listed above (port selection, retries, ...).
+	Total time.Duration
+}
+
+// Timings return the durations of the phases of the container startup.
+func (c *Container) Timings() StartupTimings {
+	return c.timings
+}
+
+// recordedStartup is a successful container startup, reported by PrintStartupSummary.
+type recordedStartup struct {
+	name    string
+	image   string
+	timings StartupTimings
+}
+
+var startupsMutex sync.Mutex
+var startups []recordedStartup
+
+// recordStartup add a successful container startup to the summary of the process (See PrintStartupSummary).
+func recordStartup(name string, image string, timings StartupTimings) {
+	startupsMutex.Lock()
+	defer startupsMutex.Unlock()
+	startups = append(startups, recordedStartup{name: name, image: image, timings: timings})
+}
+
+// PrintStartupSummary write, for every container started by the process, the number of startups and the total time spent in each phase, the slowest containers first.
+// It is intended for TestMain, to find which dependency dominate the duration of the integration tests:
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		docker.PrintStartupSummary(os.Stdout)
+//		os.Exit(code)
+//	}
+func PrintStartupSummary(w io.Writer) error {
+	startupsMutex.Lock()
+	type summary struct {
+		name    string
+		image   string
+		count   int
+		timings StartupTimings
+	}
+	summaries := make(map[string]*summary)
+	for _, startup := range startups {
+		key := startup.name + "|" + startup.image
+		current, ok := summaries[key]
+		if !ok {
+			current = &summary{name: startup.name, image: startup.image}
+			summaries[key] = current
+		}
+		current.count++
+		current.timings.Pull += startup.timings.Pull
+		current.timings.Create += startup.timings.Create
+		current.timings.Start += startup.timings.Start
+		current.timings.Ready += startup.timings.Ready
+		current.timings.Total += startup.timings.Total
+	}
+	startupsMutex.Unlock()
+
+	sorted := make([]*summary, 0, len(summaries))
+	var total time.Duration
+	for _, current := range summaries {
+		sorted = append(sorted, current)
+		total += current.timings.Total
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].timings.Total > sorted[j].timings.Total
+	})
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CONTAINER\tIMAGE\tSTARTUPS\tPULL\tCREATE\tSTART\tREADY\tTOTAL")
+	for _, current := range sorted {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			current.name,
+			current.image,
+			current.count,
+			current.timings.Pull.Round(time.Millisecond),
+			current.timings.Create.Round(time.Millisecond),
+			current.timings.Start.Round(time.Millisecond),
+			current.timings.Ready.Round(time.Millisecond),
+			current.timings.Total.Round(time.Millisecond),
+		)
+	}
+	fmt.Fprintf(writer, "\t\t\t\t\t\t\t%s\n", total.Round(time.Millisecond))
+	return writer.Flush()
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"path"
+	"strings"
+)
+
+// dataDirectories are the data directories of well-known images, indexed by repository name (without registry nor namespace, unless ambiguous).
+var dataDirectories = map[string]string{
+	"postgres":                     "/var/lib/postgresql/data",
+	"postgis/postgis":              "/var/lib/postgresql/data",
+	"timescale/timescaledb":        "/var/lib/postgresql/data",
+	"mysql":                        "/var/lib/mysql",
+	"mariadb":                      "/var/lib/mysql",
+	"mongo":                        "/data/db",
+	"redis":                        "/data",
+	"influxdb":                     "/var/lib/influxdb2",
+	"cassandra":                    "/var/lib/cassandra",
+	"scylladb/scylla":              "/var/lib/scylla",
+	"elasticsearch":                "/usr/share/elasticsearch/data",
+	"opensearchproject/opensearch": "/usr/share/opensearch/data",
+	"rabbitmq":                     "/var/lib/rabbitmq",
+	"gvenzl/oracle-xe":             "/opt/oracle/oradata",
+	"gvenzl/oracle-free":           "/opt/oracle/oradata",
+	"mssql/server":                 "/var/opt/mssql",
+}
+
+// DataDirectory return the directory in which the given image store its data, if the image is a well-known one (eg: /var/lib/postgresql/data for postgres:16).
+func DataDirectory(image string) (string, bool) {
+	repository := repositoryOf(image)
+	segments := strings.Split(repository, "/")
+	if 1 < len(segments) {
+		if directory, ok := dataDirectories[strings.Join(segments[len(segments)-2:], "/")]; ok {
+			return directory, true
+		}
+	}
+	directory, ok := dataDirectories[segments[len(segments)-1]]
+	return directory, ok
+}
+
+// TmpfsDataDirectory return the options, with the data directory of the image (See DataDirectory) mounted on a tmpfs.
+// Keeping database files in memory dramatically speed up integration tests. The options are returned unchanged if the image is unknown.
+func TmpfsDataDirectory(options Options) Options {
+	directory, ok := DataDirectory(options.Image)
+	if !ok {
+		return options
+	}
+	mounts := make(map[string]string, len(options.TmpfsMounts)+1)
+	for path, mountOptions := range options.TmpfsMounts {
+		mounts[path] = mountOptions
+	}
+	if _, exists := mounts[directory]; !exists {
+		mounts[directory] = "rw"
+	}
+	options.TmpfsMounts = mounts
+	return options
+}
+
+// writablePaths are the paths, other than /tmp, /run and the data directory, that well-known images write to at runtime, indexed by repository name (See dataDirectories).
+var writablePaths = map[string][]string{
+	"nginx":    {"/var/cache/nginx"},
+	"mysql":    {"/var/lib/mysql-files"},
+	"mongo":    {"/data/configdb"},
+	"rabbitmq": {"/var/log/rabbitmq"},
+}
+
+// WritablePaths return the paths the given image need writable to run (See Options.ReadonlyRootfs): /tmp, /run, and for well-known images their data directory (See DataDirectory) and the other paths they write to.
+func WritablePaths(image string) []string {
+	paths := []string{"/tmp", "/run"}
+	if directory, ok := DataDirectory(image); ok {
+		paths = append(paths, directory)
+	}
+	segments := strings.Split(repositoryOf(image), "/")
+	return append(paths, writablePaths[segments[len(segments)-1]]...)
+}
+
+// tmpfs return the tmpfs mounts of the container: TmpfsMounts, SecretsDirectory if the container has secrets (See Options.Secrets) and, with a read-only root filesystem, the paths the image need writable which are not already mounted.
+func tmpfs(options Options) map[string]string {
+	if !options.ReadonlyRootfs && 0 == len(options.Secrets) {
+		return options.TmpfsMounts
+	}
+	mounted := make(map[string]bool, len(options.Binds))
+	for _, bind := range options.Binds {
+		mounted[path.Clean(bind.ContainerPath)] = true
+	}
+	mounts := make(map[string]string, len(options.TmpfsMounts)+5)
+	for mountPath, mountOptions := range options.TmpfsMounts {
+		mounts[mountPath] = mountOptions
+		mounted[path.Clean(mountPath)] = true
+	}
+	if 0 != len(options.Secrets) {
+		mounts[SecretsDirectory] = secretsMount()
+	}
+	if !options.ReadonlyRootfs {
+		return mounts
+	}
+	for _, writable := range WritablePaths(options.Image) {
+		if mounted[writable] {
+			continue
+		}
+		mounts[writable] = "rw"
+		if "/tmp" == writable {
+			// Unlike the docker default, allow to execute files from /tmp (eg: native libraries extracted by the JVM)
+			mounts[writable] = "rw,exec"
+		}
+	}
+	return mounts
+}
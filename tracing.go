@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tracer record the startup of the containers as spans (See Options.Tracer): a span covering the whole startup, parent of the spans of the pull, create, start and readiness phases.
+// The github.com/normegil/docker/oteltrace module implement it with OpenTelemetry.
+type Tracer interface {
+	// Start a span with the given name, child of the span of ctx if any, and return a context containing the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation recorded by a Tracer.
+type Span interface {
+	// SetAttributes add attributes to the span (See AttributeContainerID, ...).
+	SetAttributes(attributes map[string]string)
+	// End the span. If err is not nil, the span is marked as failed.
+	End(err error)
+}
+
+// Names of the spans created during the startup of a container.
+const (
+	SpanStartup   = "docker.startup"
+	SpanBuild     = "docker.build"
+	SpanPull      = "docker.pull"
+	SpanCreate    = "docker.create"
+	SpanStart     = "docker.start"
+	SpanReadiness = "docker.readiness"
+)
+
+// Attributes added to the spans.
+const (
+	AttributeContainerName = "container.name"
+	AttributeContainerID   = "container.id"
+	AttributeImage         = "container.image"
+	// AttributePorts are the selected host ports, as a comma separated list of internal=host ports (eg: 5432/tcp=30001).
+	AttributePorts = "container.ports"
+)
+
+// noSpan is the span of phases which are not traced.
+type noSpan struct{}
+
+func (noSpan) SetAttributes(attributes map[string]string) {}
+
+func (noSpan) End(err error) {}
+
+// startupTrace is the span of a container startup, parent of the spans of its phases.
+type startupTrace struct {
+	tracer Tracer
+	ctx    context.Context
+	span   Span
+}
+
+// startTrace start the span of the container startup. Nil is returned if no Tracer is configured.
+func startTrace(options Options) *startupTrace {
+	if nil == options.Tracer {
+		return nil
+	}
+	ctx, span := options.Tracer.Start(context.Background(), SpanStartup)
+	span.SetAttributes(map[string]string{AttributeContainerName: options.Name, AttributeImage: options.Image})
+	return &startupTrace{tracer: options.Tracer, ctx: ctx, span: span}
+}
+
+// phase start the span of a startup phase, with the given attributes.
+func (t *startupTrace) phase(name string, attributes map[string]string) Span {
+	if nil == t {
+		return noSpan{}
+	}
+	_, span := t.tracer.Start(t.ctx, name)
+	span.SetAttributes(attributes)
+	return span
+}
+
+// finish end the span of the startup. The attributes of the container are added if it is ready, and failure (the last error of a phase) is reported otherwise.
+func (t *startupTrace) finish(info *ContainerInfo, failure error) {
+	if nil == t {
+		return
+	}
+	if nil != info {
+		t.span.SetAttributes(map[string]string{AttributeContainerID: info.Identifier, AttributePorts: portsAttribute(info.Ports)})
+		t.span.End(nil)
+		return
+	}
+	t.span.End(failure)
+}
+
+// portsAttribute format the selected host ports (See AttributePorts).
+func portsAttribute(ports map[PortBinding]int) string {
+	formatted := make([]string, 0, len(ports))
+	for binding, port := range ports {
+		formatted = append(formatted, strconv.Itoa(binding.Internal)+"/"+binding.protocol()+"="+strconv.Itoa(port))
+	}
+	sort.Strings(formatted)
+	return strings.Join(formatted, ",")
+}
@@ -0,0 +1,198 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	volumetypes "github.com/docker/docker/api/types/volume"
+	docker "github.com/docker/docker/client"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// volumeHelperImage is the image of the containers accessing the content of volumes (See Volume.CopyTar, Volume.Snapshot).
+const volumeHelperImage = "busybox:1.36"
+
+// volumeHelperPath is the path on which the volume is mounted in the helper containers.
+const volumeHelperPath = "/volume"
+
+// VolumeOptions describe a named volume (See NewVolume).
+type VolumeOptions struct {
+	// Name of the volume. Default to a generated unique name.
+	Name string
+	// Driver of the volume. Default to the "local" driver.
+	Driver string
+	// DriverOptions are the options of the driver (eg: type=tmpfs, device=tmpfs and o=size=100m, for a local volume in memory).
+	DriverOptions map[string]string
+	// Labels to add to the volume. Labels managed by this package (See LabelSession) are always added, so that the volume is removed with the session resources (See CleanupSession).
+	Labels map[string]string
+	// Logger used for the volume operations.
+	Logger Logger
+	// Backend on which the volume is created. Default to DetectBackend().
+	Backend ContainerBackend
+	// Client, if specified, is used to create the volume (See Options.Client).
+	Client *docker.Client
+}
+
+// Volume is a named volume, which can be mounted in several containers (See Bind), and outlive them (eg: to restart a database on the same data).
+type Volume struct {
+	// Name of the volume.
+	Name string
+
+	client *docker.Client
+	logger *eventLogger
+	// backend is the backend of the volume, on which the helper containers are created.
+	backend ContainerBackend
+}
+
+// NewVolume create a named volume. It must be removed once not needed anymore (See Volume.Remove, or Session.NewVolume to remove it with the other resources of a session).
+func NewVolume(ctx context.Context, options VolumeOptions) (*Volume, error) {
+	l := newLogger(Options{Logger: options.Logger})
+	client, err := clientOf(Options{Backend: options.Backend, Client: options.Client}, l)
+	if nil != err {
+		return nil, withKind(ErrDaemonUnavailable, errors.Wrap(err, "Could not create docker client"))
+	}
+	name := options.Name
+	if "" == name {
+		name = "volume-" + uuid.New().String()
+	}
+	l.Printf("Creating volume: " + name)
+	created, err := client.VolumeCreate(ctx, volumetypes.VolumesCreateBody{
+		Name:       name,
+		Driver:     options.Driver,
+		DriverOpts: options.DriverOptions,
+		Labels:     labels(Options{Labels: options.Labels}),
+	})
+	if nil != err {
+		return nil, errors.Wrapf(err, "Creating volume %s", name)
+	}
+	return &Volume{Name: created.Name, client: client, logger: l, backend: options.Backend}, nil
+}
+
+// Bind return the bind mounting the volume at the given path of a container (See Options.Binds).
+func (v *Volume) Bind(containerPath string, readOnly bool) Bind {
+	return Bind{HostPath: v.Name, ContainerPath: containerPath, ReadOnly: readOnly}
+}
+
+// CopyDir copy the content of the host directory at the root of the volume (eg: a seeded database data directory, or fixtures), before mounting it in the containers.
+func (v *Volume) CopyDir(ctx context.Context, directory string) error {
+	archive, err := archiveDirectory(directory, nil)
+	if nil != err {
+		return errors.Wrapf(err, "Archiving %s", directory)
+	}
+	return v.CopyTar(ctx, archive)
+}
+
+// CopyTar extract the tar stream at the root of the volume. The volume is populated through a container, created from a small image but never started.
+func (v *Volume) CopyTar(ctx context.Context, archive io.Reader) error {
+	return v.withHelper(ctx, nil, func(id string) error {
+		v.logger.Printf("Copying content into volume: " + v.Name)
+		if err := v.client.CopyToContainer(ctx, id, volumeHelperPath, archive, types.CopyToContainerOptions{}); nil != err {
+			return errors.Wrapf(err, "Copying content into volume %s", v.Name)
+		}
+		return nil
+	})
+}
+
+// VolumeSnapshot is the content of a volume at a point in time (See Volume.Snapshot, Container.SnapshotVolume). It is held in memory.
+type VolumeSnapshot struct {
+	// Path is the path on which the volume is mounted in the snapshotted container. It is empty for snapshots of Volume.
+	Path string
+	// archive is the tar archive of the volume, with paths prefixed by the base name of volumeHelperPath.
+	archive []byte
+}
+
+// Size return the size of the snapshot, in bytes.
+func (s *VolumeSnapshot) Size() int {
+	return len(s.archive)
+}
+
+// Snapshot archive the content of the volume, including the ownership and permissions of its files. The volume must not be modified during the snapshot (eg: by a running database).
+func (v *Volume) Snapshot(ctx context.Context) (*VolumeSnapshot, error) {
+	snapshot := &VolumeSnapshot{}
+	err := v.withHelper(ctx, nil, func(id string) error {
+		v.logger.Printf("Snapshotting volume: " + v.Name)
+		reader, _, err := v.client.CopyFromContainer(ctx, id, volumeHelperPath)
+		if nil != err {
+			return errors.Wrapf(err, "Archiving volume %s", v.Name)
+		}
+		defer reader.Close()
+		if snapshot.archive, err = ioutil.ReadAll(reader); nil != err {
+			return errors.Wrapf(err, "Reading archive of volume %s", v.Name)
+		}
+		return nil
+	})
+	if nil != err {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Restore replace the content of the volume by the content of the snapshot. The volume must not be used during the restoration (eg: by a running database).
+// The snapshot is extracted inside a container, so that the ownership of the files is restored.
+func (v *Volume) Restore(ctx context.Context, snapshot *VolumeSnapshot) error {
+	const archivePath = "/snapshot.tar"
+	cmd := []string{"sh", "-c", "find " + volumeHelperPath + " -mindepth 1 -delete && tar -xf " + archivePath + " -C " + path.Dir(volumeHelperPath)}
+	return v.withHelper(ctx, cmd, func(id string) error {
+		v.logger.Printf("Restoring volume: " + v.Name)
+		if err := copyFiles(v.client, id, []File{{ContainerPath: archivePath, Content: snapshot.archive}}); nil != err {
+			return errors.Wrapf(err, "Copying snapshot of volume %s", v.Name)
+		}
+		if err := v.client.ContainerStart(ctx, id, types.ContainerStartOptions{}); nil != err {
+			return errors.Wrapf(err, "Starting container restoring volume %s", v.Name)
+		}
+		code, err := v.client.ContainerWait(ctx, id)
+		if nil != err {
+			return errors.Wrapf(err, "Waiting for container restoring volume %s", v.Name)
+		}
+		if 0 != code {
+			logs, _ := containerLogs(ctx, v.client, id)
+			return errors.Errorf("Restoring volume %s failed (Exit code: %d): %s", v.Name, code, strings.TrimSpace(string(logs)))
+		}
+		return nil
+	})
+}
+
+// withHelper create a container mounting the volume on volumeHelperPath, call use with its ID, and remove it. The container run cmd if use start it.
+func (v *Volume) withHelper(ctx context.Context, cmd []string, use func(id string) error) error {
+	options := Options{Image: volumeHelperImage, Backend: v.backend, Client: v.client}
+	loaded, err := LoadConfig()
+	if nil != err {
+		return err
+	}
+	options = withConfig(options, loaded)
+	if err := pullImage(ctx, v.client, options); nil != err {
+		return errors.Wrapf(err, "Pulling %s", options.Image)
+	}
+	created, err := v.client.ContainerCreate(ctx, &container.Config{
+		Image:  options.Image,
+		Cmd:    strslice.StrSlice(cmd),
+		Labels: labels(Options{}),
+	}, &container.HostConfig{
+		Binds: []string{v.Bind(volumeHelperPath, false).String()},
+	}, nil, "")
+	if nil != err {
+		return errors.Wrapf(err, "Creating container accessing volume %s", v.Name)
+	}
+	defer func() {
+		if err := v.client.ContainerRemove(context.Background(), created.ID, types.ContainerRemoveOptions{Force: true}); nil != err {
+			v.logger.Warnf("Could not remove container accessing volume %s: %+v", v.Name, err)
+		}
+	}()
+	return use(created.ID)
+}
+
+// Remove remove the volume. It fail if the volume is still used by a container.
+func (v *Volume) Remove(ctx context.Context) error {
+	v.logger.Printf("Removing volume: " + v.Name)
+	if err := v.client.VolumeRemove(ctx, v.Name, true); nil != err && !docker.IsErrVolumeNotFound(err) {
+		return errors.Wrapf(err, "Removing volume %s", v.Name)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	docker "github.com/docker/docker/client"
+)
+
+// WaitStrategy define how to know that the service inside a container is ready to be used.
+type WaitStrategy interface {
+	// WaitUntilReady block until the service inside the target container is ready, or return an error if the context is done before.
+	WaitUntilReady(ctx context.Context, target WaitTarget) error
+}
+
+// WaitTarget is the container a WaitStrategy is waiting for.
+type WaitTarget struct {
+	ContainerInfo
+	client    *docker.Client
+	readiness Backoff
+	progress  *startupTracker
+}
+
+// Exec run the given command inside the container, and return its exit code and combined output (stdout and stderr).
+func (t WaitTarget) Exec(ctx context.Context, cmd ...string) (int, []byte, error) {
+	return execute(ctx, t.client, t.Identifier, cmd)
+}
+
+// Logs return the combined logs (stdout and stderr) of the container.
+func (t WaitTarget) Logs(ctx context.Context) ([]byte, error) {
+	return containerLogs(ctx, t.client, t.Identifier)
+}
+
+// WaitStrategyFunc allow to use a simple function as a WaitStrategy.
+type WaitStrategyFunc func(ctx context.Context, target WaitTarget) error
+
+// WaitUntilReady call the function itself.
+func (f WaitStrategyFunc) WaitUntilReady(ctx context.Context, target WaitTarget) error {
+	return f(ctx, target)
+}
+
+// ForAll wait for all the given strategies, one after the other.
+func ForAll(strategies ...WaitStrategy) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		for _, strategy := range strategies {
+			if err := strategy.WaitUntilReady(ctx, target); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ForRunning only wait for the container to be running, skipping any readiness check of the service inside (eg: for images only exposing UDP or SCTP ports).
+func ForRunning() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		return nil
+	})
+}
+
+// ForListeningPort wait for the given TCP port to accept connections.
+func ForListeningPort(binding PortBinding) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		if ProtocolTCP != binding.protocol() {
+			return fmt.Errorf("Cannot check if a %s port is listening: %d", binding.Protocol, binding.Internal)
+		}
+		hostport := target.Endpoint(binding)
+		var dialer net.Dialer
+		err := poll(ctx, target, func() error {
+			c, err := dialer.DialContext(ctx, "tcp", hostport)
+			if nil != err {
+				return err
+			}
+			return c.Close()
+		}, "Could not reach "+hostport)
+		if nil != err && !target.Direct {
+			if conflict := portConflict(target.HostPort(binding, binding.Internal)); "" != conflict {
+				return fmt.Errorf("%s: %+v", conflict, err)
+			}
+		}
+		return err
+	})
+}
+
+// poll call the given function, with the target readiness backoff between calls, until it succeed or the context is done. In the latter case, the last error is returned, prefixed by the given message.
+// Polling stop as soon as the container is no longer running (eg: killed for exceeding its memory limit), as it will never be ready.
+func poll(ctx context.Context, target WaitTarget, try func() error, message string) error {
+	start := time.Now()
+	target.progress.waiting(message)
+	err := target.readiness.Retry(ctx, func() error {
+		err := try()
+		if nil != err {
+			target.progress.waiting(message + ": " + err.Error())
+			if stopped := target.stopped(ctx); nil != stopped {
+				return stopped
+			}
+		}
+		return err
+	}, stillRunning)
+	if nil != err {
+		return fmt.Errorf("%s {WaitingTime: %v}: %v", message, time.Since(start), err)
+	}
+	return nil
+}
+
+// errNotRunning is returned while waiting for a container which exited, which is not worth waiting for.
+type errNotRunning struct {
+	status   string
+	exitCode int
+}
+
+func (e errNotRunning) Error() string {
+	return fmt.Sprintf("Container not running {Status: %s, ExitCode: %d}", e.status, e.exitCode)
+}
+
+// stopped return errOOMKilled or errNotRunning if the container is no longer running. Errors while inspecting the container are ignored, and left to the readiness check.
+func (t WaitTarget) stopped(ctx context.Context) error {
+	c, err := t.client.ContainerInspect(ctx, t.Identifier)
+	if nil != err {
+		return nil
+	}
+	if c.State.OOMKilled {
+		return errOOMKilled
+	}
+	if !c.State.Running {
+		return errNotRunning{status: c.State.Status, exitCode: c.State.ExitCode}
+	}
+	return nil
+}
+
+// stillRunning check if waiting for the container is still worth it (See Backoff.Retry).
+func stillRunning(err error) bool {
+	_, exited := err.(errNotRunning)
+	return notOOMKilled(err) && !exited
+}
+
+func waitStrategy(options Options) WaitStrategy {
+	if nil != options.WaitStrategy {
+		return options.WaitStrategy
+	}
+	if 0 == len(options.Ports) {
+		return forPublishedPorts()
+	}
+	if ProtocolTCP != options.Ports[0].protocol() {
+		return ForRunning()
+	}
+	return ForListeningPort(options.Ports[0])
+}
+
+// forPublishedPorts wait for every published TCP port of the container to accept connections.
+func forPublishedPorts() WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		for binding := range target.Ports {
+			if ProtocolTCP != binding.protocol() {
+				continue
+			}
+			if err := ForListeningPort(binding).WaitUntilReady(ctx, target); nil != err {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func waitContainer(client *docker.Client, info ContainerInfo, strategy WaitStrategy, maxWait time.Duration, retries Retries, tracker *startupTracker) error {
+	ctx, cancel := context.WithTimeout(context.Background(), maxWait)
+	defer cancel()
+	span := tracker.phase(SpanReadiness, map[string]string{AttributeContainerID: info.Identifier, AttributePorts: portsAttribute(info.Ports)})
+	err := waitStarted(ctx, client, info.Identifier, retries.Daemon)
+	if nil == err {
+		tracker.waiting("waiting for readiness")
+		err = strategy.WaitUntilReady(ctx, WaitTarget{ContainerInfo: info, client: client, readiness: retries.Readiness, progress: tracker})
+	}
+	span.End(err)
+	if nil != err {
+		tracker.notReady()
+	}
+	return err
+}
+
+func waitStarted(ctx context.Context, client *docker.Client, containerID string, backoff Backoff) error {
+	start := time.Now()
+	err := backoff.Retry(ctx, func() error {
+		c, err := client.ContainerInspect(ctx, containerID)
+		if err != nil {
+			return err
+		}
+		if c.State.OOMKilled {
+			return errOOMKilled
+		}
+		if !c.State.Running {
+			return fmt.Errorf("Container status: %s", c.State.Status)
+		}
+		return nil
+	}, notOOMKilled)
+	if nil != err {
+		return fmt.Errorf("Container not started: %s {WaitingTime: %v}: %v", containerID, time.Since(start), err)
+	}
+	return nil
+}
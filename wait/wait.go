@@ -0,0 +1,61 @@
+// Package wait gather the strategies used to know when the service inside a container is ready to be used.
+// It is a facade over the wait strategies of the root package (docker.ForLog, docker.WaitStrategy, ...), which stay available: both can be used interchangeably.
+package wait
+
+import (
+	"github.com/normegil/docker"
+)
+
+// Strategy define how to know that the service inside a container is ready to be used (See docker.Options.WaitStrategy).
+type Strategy = docker.WaitStrategy
+
+// Target is the container a Strategy is waiting for.
+type Target = docker.WaitTarget
+
+// StrategyFunc allow to use a simple function as a Strategy.
+type StrategyFunc = docker.WaitStrategyFunc
+
+// ForAll wait for all the given strategies, one after the other.
+func ForAll(strategies ...Strategy) Strategy {
+	return docker.ForAll(strategies...)
+}
+
+// ForRunning only wait for the container to be running, skipping any readiness check of the service inside.
+func ForRunning() Strategy {
+	return docker.ForRunning()
+}
+
+// ForListeningPort wait for the given TCP port to accept connections.
+func ForListeningPort(binding docker.PortBinding) Strategy {
+	return docker.ForListeningPort(binding)
+}
+
+// ForLog wait for the container logs to match the given regular expression, at least the given number of times.
+func ForLog(pattern string, occurrences int) Strategy {
+	return docker.ForLog(pattern, occurrences)
+}
+
+// ForExec wait for the given command to succeed (exit code 0) inside the container.
+func ForExec(cmd ...string) Strategy {
+	return docker.ForExec(cmd...)
+}
+
+// ForHTTP wait for the given path to answer, and for check to accept the response.
+func ForHTTP(binding docker.PortBinding, path string, check func(status int, body []byte) bool) Strategy {
+	return docker.ForHTTP(binding, path, check)
+}
+
+// ForMetric wait for the given Prometheus metric to satisfy the condition.
+func ForMetric(binding docker.PortBinding, path string, metric string, condition func(value float64) bool) Strategy {
+	return docker.ForMetric(binding, path, metric, condition)
+}
+
+// AtLeast is a ForMetric condition, satisfied when the metric is greater or equal to the threshold.
+func AtLeast(threshold float64) func(value float64) bool {
+	return docker.AtLeast(threshold)
+}
+
+// ForWebsocket wait for a websocket handshake to succeed on the given path.
+func ForWebsocket(binding docker.PortBinding, path string) Strategy {
+	return docker.ForWebsocket(binding, path)
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ForLog wait for the container logs to match the given regular expression, at least the given number of times.
+// Some images log the same message multiple times during a multi-phase startup (eg: once for a temporary server used during initialisation, once for the final server).
+func ForLog(pattern string, occurrences int) WaitStrategy {
+	expression := regexp.MustCompile(pattern)
+	if occurrences < 1 {
+		occurrences = 1
+	}
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		return poll(ctx, target, func() error {
+			logs, err := target.Logs(ctx)
+			if nil != err {
+				return err
+			}
+			if found := len(expression.FindAllIndex(logs, -1)); found < occurrences {
+				return fmt.Errorf("Found %d/%d occurrence(s)", found, occurrences)
+			}
+			return nil
+		}, "Log '"+pattern+"' not found")
+	})
+}
+
+// ForExec wait for the given command, executed inside the container, to exit successfully (eg: a query through the database CLI).
+func ForExec(cmd ...string) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		return poll(ctx, target, func() error {
+			code, output, err := target.Exec(ctx, cmd...)
+			if nil != err {
+				return err
+			}
+			if 0 != code {
+				return fmt.Errorf("Exit code %d: %s", code, strings.TrimSpace(string(output)))
+			}
+			return nil
+		}, "Command "+strings.Join(cmd, " ")+" did not succeed")
+	})
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// ForHTTP wait for a GET request on the given port and path (eg: /health) to return a response satisfying check.
+// If check is nil, any 2xx status is accepted.
+func ForHTTP(binding PortBinding, path string, check func(status int, body []byte) bool) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		url := "http://" + target.Endpoint(binding) + path
+		return poll(ctx, target, func() error {
+			return checkHTTP(ctx, url, check)
+		}, "HTTP endpoint not ready: "+url)
+	})
+}
+
+func checkHTTP(ctx context.Context, url string, check func(status int, body []byte) bool) error {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if nil != err {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if nil != err {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if nil != err {
+		return err
+	}
+	if nil == check {
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			return fmt.Errorf("Unexpected status: %s", response.Status)
+		}
+		return nil
+	}
+	if !check(response.StatusCode, body) {
+		return fmt.Errorf("Unexpected response: %s: %s", response.Status, body)
+	}
+	return nil
+}
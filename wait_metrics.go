@@ -0,0 +1,95 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ForMetric wait for a Prometheus metric, exposed by the container on the given port and path (eg: /metrics), to satisfy the given condition.
+// Every sample whose name start with metric is tested (eg: "kafka_server_brokertopicmetrics" match "kafka_server_brokertopicmetrics_messagesin_total").
+// If condition is nil, the strategy only wait for the metric to be present.
+func ForMetric(binding PortBinding, path string, metric string, condition func(value float64) bool) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		url := "http://" + target.Endpoint(binding) + path
+		return poll(ctx, target, func() error {
+			return checkMetric(ctx, url, metric, condition)
+		}, "Metric "+metric+" not ready on "+url)
+	})
+}
+
+// AtLeast return a condition, to use with ForMetric, satisfied when the metric value is greater or equal to threshold.
+func AtLeast(threshold float64) func(value float64) bool {
+	return func(value float64) bool {
+		return value >= threshold
+	}
+}
+
+func checkMetric(ctx context.Context, url string, metric string, condition func(value float64) bool) error {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if nil != err {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request.WithContext(ctx))
+	if nil != err {
+		return err
+	}
+	defer response.Body.Close()
+	if http.StatusOK != response.StatusCode {
+		return fmt.Errorf("Unexpected status: %s", response.Status)
+	}
+
+	found := false
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		name, value, ok := parseSample(scanner.Text())
+		if !ok || !strings.HasPrefix(name, metric) {
+			continue
+		}
+		found = true
+		if nil == condition || condition(value) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); nil != err {
+		return err
+	}
+	if found {
+		return fmt.Errorf("Metric %s does not satisfy condition", metric)
+	}
+	return fmt.Errorf("Metric %s not found", metric)
+}
+
+// parseSample parse a sample line of the Prometheus text format ("name{labels} value [timestamp]").
+func parseSample(line string) (string, float64, bool) {
+	line = strings.TrimSpace(line)
+	if "" == line || strings.HasPrefix(line, "#") {
+		return "", 0, false
+	}
+	name := line
+	rest := ""
+	if index := strings.IndexAny(line, "{ \t"); -1 != index {
+		name = line[:index]
+		rest = line[index:]
+	}
+	if strings.HasPrefix(rest, "{") {
+		end := strings.LastIndex(rest, "}")
+		if -1 == end {
+			return "", 0, false
+		}
+		rest = rest[end+1:]
+	}
+	fields := strings.Fields(rest)
+	if 0 == len(fields) {
+		return "", 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if nil != err {
+		return "", 0, false
+	}
+	return name, value, true
+}
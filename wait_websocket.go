@@ -0,0 +1,72 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ForWebsocket wait for a websocket handshake to succeed on the given port and path (eg: /ws).
+// Useful for realtime services, whose HTTP endpoints are available before the websocket upgrade path works.
+func ForWebsocket(binding PortBinding, path string) WaitStrategy {
+	return WaitStrategyFunc(func(ctx context.Context, target WaitTarget) error {
+		hostport := target.Endpoint(binding)
+		return poll(ctx, target, func() error {
+			return websocketHandshake(ctx, hostport, path)
+		}, "Websocket handshake failed on "+hostport+path)
+	})
+}
+
+func websocketHandshake(ctx context.Context, hostport string, path string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", hostport)
+	if nil != err {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(maxWaitTime))
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); nil != err {
+		return err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	request, err := http.NewRequest(http.MethodGet, "http://"+hostport+path, nil)
+	if nil != err {
+		return err
+	}
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	if err := request.Write(conn); nil != err {
+		return err
+	}
+
+	response, err := http.ReadResponse(bufio.NewReader(conn), request)
+	if nil != err {
+		return err
+	}
+	response.Body.Close()
+	if http.StatusSwitchingProtocols != response.StatusCode {
+		return fmt.Errorf("Unexpected status: %s", response.Status)
+	}
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	if expected := base64.StdEncoding.EncodeToString(hash[:]); response.Header.Get("Sec-WebSocket-Accept") != expected {
+		return fmt.Errorf("Invalid Sec-WebSocket-Accept header: %s", response.Header.Get("Sec-WebSocket-Accept"))
+	}
+	return nil
+}
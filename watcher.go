@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	docker "github.com/docker/docker/client"
+)
+
+const defaultReloadInterval = 500 * time.Millisecond
+
+// Reload define how a container is notified when one of its bind-mounted paths (See Options.Binds) is modified on the host, to test configuration reload behaviors.
+// Modifications are detected by polling the modification time and size of the files.
+type Reload struct {
+	// Signal to send to the container main process (eg: SIGHUP).
+	Signal string
+	// Exec is a command to execute inside the container (eg: nginx -s reload). Executed after sending Signal, if both are specified.
+	Exec []string
+	// Interval between two checks of the bind-mounted paths. Default to 500ms.
+	Interval time.Duration
+}
+
+// watch start watching the bind-mounted paths of the container. The returned function stop watching.
+func (r *Reload) watch(client *docker.Client, l *eventLogger, containerID string, containerName string, binds []Bind) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		interval := r.Interval
+		if 0 >= interval {
+			interval = defaultReloadInterval
+		}
+		last := fingerprint(binds)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			current := fingerprint(binds)
+			if current == last {
+				continue
+			}
+			last = current
+			l.Printf("Bind-mounted files changed, reloading container: %s", containerName)
+			r.reload(ctx, client, l, containerID, containerName)
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func (r *Reload) reload(ctx context.Context, client *docker.Client, l *eventLogger, containerID string, containerName string) {
+	if "" != r.Signal {
+		if err := client.ContainerKill(ctx, containerID, r.Signal); nil != err {
+			l.Warnf("Could not send %s to %s: %+v", r.Signal, containerName, err)
+		}
+	}
+	if 0 != len(r.Exec) {
+		code, output, err := execute(ctx, client, containerID, r.Exec)
+		if nil != err {
+			l.Warnf("Could not execute %s in %s: %+v", strings.Join(r.Exec, " "), containerName, err)
+		} else if 0 != code {
+			l.Warnf("Reload command %s failed in %s (Exit code: %d): %s", strings.Join(r.Exec, " "), containerName, code, output)
+		}
+	}
+}
+
+// fingerprint summarize the modification times and sizes of every file under the bind-mounted host paths.
+func fingerprint(binds []Bind) string {
+	var builder strings.Builder
+	for _, bind := range binds {
+		filepath.Walk(bind.HostPath, func(path string, info os.FileInfo, err error) error {
+			if nil != err {
+				builder.WriteString(path + ":error;")
+				return nil
+			}
+			builder.WriteString(path + ":" + info.ModTime().String() + ":" + strconv.FormatInt(info.Size(), 10) + ";")
+			return nil
+		})
+	}
+	return builder.String()
+}
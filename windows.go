@@ -0,0 +1,48 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// windowsOS is the operating system of Windows container images.
+const windowsOS = "windows"
+
+// checkPlatformOptions check that the options are supported by the operating system of the image. Windows containers have no tmpfs (and so no secrets), read-only root filesystem, init process, privileged mode, capabilities, sysctls, cgroup parent, namespace modes nor devices mapping.
+func checkPlatformOptions(options Options, image ImageInfo) error {
+	if windowsOS != image.OS {
+		if "" != options.Isolation && "default" != options.Isolation {
+			return fmt.Errorf("Isolation is only supported by Windows containers (image OS: %s)", image.OS)
+		}
+		return nil
+	}
+	unsupported := make([]string, 0)
+	if 0 != len(options.TmpfsMounts) || 0 != len(options.Secrets) {
+		unsupported = append(unsupported, "TmpfsMounts/Secrets")
+	}
+	if options.ReadonlyRootfs {
+		unsupported = append(unsupported, "ReadonlyRootfs")
+	}
+	if options.Init {
+		unsupported = append(unsupported, "Init")
+	}
+	if options.Privileged {
+		unsupported = append(unsupported, "Privileged")
+	}
+	if 0 != len(options.CapAdd) || 0 != len(options.CapDrop) {
+		unsupported = append(unsupported, "CapAdd/CapDrop")
+	}
+	if 0 != len(options.Sysctls) {
+		unsupported = append(unsupported, "Sysctls")
+	}
+	if "" != options.CgroupParent || "" != options.PidMode || "" != options.IpcMode || "" != options.UsernsMode {
+		unsupported = append(unsupported, "CgroupParent/PidMode/IpcMode/UsernsMode")
+	}
+	if 0 != len(options.Devices) || "" != options.GPUs {
+		unsupported = append(unsupported, "Devices/GPUs")
+	}
+	if 0 != len(unsupported) {
+		return fmt.Errorf("Not supported by Windows containers: %s", strings.Join(unsupported, ", "))
+	}
+	return nil
+}